@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/data"
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the standards configuration",
+	Long:  `Config inspects where standards are loaded from and how configuration files are combined.`,
+}
+
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Show which configuration sources were loaded and how they merged",
+	Long: `Sources lists every configuration layer in merge order, from the built-in defaults through
+system and user files, $KEYLENGTH_CONFIG and --config. Later layers replace standards of the same name.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd, "")
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+
+		layers := cfg.Layers()
+		display.PrintSection("Configuration Sources", "")
+		t := display.CreateTable()
+		t.AppendHeader(table.Row{"Priority", "Source", "Path", "Standards", "Overrides"})
+		for i, layer := range layers {
+			path := layer.Path
+			if path == "" {
+				path = "(built-in)"
+			}
+			overrides := strings.Join(layer.Overrides, ", ")
+			if overrides == "" {
+				overrides = "-"
+			}
+			t.AppendRow(table.Row{strconv.Itoa(i + 1), layer.Name, path, strings.Join(layer.Standards, ", "), overrides})
+		}
+		t.SetColumnConfigs([]table.ColumnConfig{
+			{Number: 3, WidthMax: 45},
+			{Number: 4, WidthMax: 40, WidthMaxEnforcer: text.WrapSoft},
+			{Number: 5, WidthMax: 30},
+		})
+		t.Render()
+
+		winner := layers[len(layers)-1]
+		fmt.Println()
+		display.PrintInfo(display.FormatKeyValue("Highest priority source", winner.Name))
+
+		display.PrintSection("Effective Standards", "")
+		t = display.CreateTable()
+		t.AppendHeader(table.Row{"Standard", "Defined By", "Replaced"})
+		for _, name := range cfg.AvailableStandards() {
+			origins := cfg.Origins(name)
+			replaced := strings.Join(origins[:len(origins)-1], ", ")
+			if replaced == "" {
+				replaced = "-"
+			}
+			t.AppendRow(table.Row{name, origins[len(origins)-1], replaced})
+		}
+		t.Render()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate a standards file against the published schema",
+	Long: `Validate checks a JSON, YAML or TOML standards file against the published JSON Schema and
+reports each problem with its line and column. Cut-off years in the past are reported as warnings.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := args[0]
+		issues, err := config.ValidateFile(file)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Error reading file '%s': %v", file, err))
+			os.Exit(exitError)
+		}
+
+		errorCount := 0
+		for _, issue := range issues {
+			severity := "Failed"
+			if issue.Warning {
+				severity = "Warning"
+			} else {
+				errorCount++
+			}
+			location := file
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d:%d", file, issue.Line, issue.Column)
+			}
+			path := ""
+			if issue.Path != "" {
+				path = issue.Path + ": "
+			}
+			fmt.Printf("%s %s: %s%s\n", display.FormatStatus(severity), location, path, issue.Message)
+		}
+
+		if errorCount == 0 {
+			layer, err := config.FileLayer(file)
+			if err == nil {
+				_, err = config.NewLayeredConfig([]config.Layer{config.EmbeddedLayer(), layer}, "")
+			}
+			if err != nil {
+				display.PrintError(err.Error())
+				os.Exit(exitError)
+			}
+		}
+
+		if errorCount > 0 {
+			display.PrintError(fmt.Sprintf("%s has %d schema error(s)", file, errorCount))
+			os.Exit(exitError)
+		}
+		fmt.Printf("[%s] %s is valid\n", display.SuccessSymbol, file)
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for standards files",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(string(data.Schema))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSourcesCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -2,7 +2,9 @@ package display
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -22,16 +24,38 @@ var (
 	InfoSymbol    = ">"
 )
 
+// out receives everything but errors, which go to errOut.
+var (
+	out    io.Writer = os.Stdout
+	errOut io.Writer = os.Stdout
+)
+
+// SetOutput sends human-readable output to w and errors to errW. Machine
+// output formats discard the former so only the report reaches stdout.
+func SetOutput(w, errW io.Writer) {
+	out, errOut = w, errW
+}
+
+// Printf writes to the human-readable output.
+func Printf(format string, args ...any) {
+	fmt.Fprintf(out, format, args...)
+}
+
+// Println writes to the human-readable output.
+func Println(args ...any) {
+	fmt.Fprintln(out, args...)
+}
+
 func PrintLogo() {}
 
 func PrintSection(title string, _ string) {
-	fmt.Printf("\n--- %s ---\n", strings.ToUpper(title))
+	fmt.Fprintf(out, "\n--- %s ---\n", strings.ToUpper(title))
 }
 
 func NewSpinner(text string) spinner.Model {
 	s := spinner.New()
 	s.Spinner = spinner.Line
-	fmt.Printf("%s %s... ", s.View(), text)
+	fmt.Fprintf(out, "%s %s... ", s.View(), text)
 	return s
 }
 
@@ -42,7 +66,7 @@ func StopSpinner(s spinner.Model, success bool) {
 		finalMsg = "Failed."
 		finalSymbol = ErrorSymbol
 	}
-	fmt.Printf("\r%s %s          \n", finalSymbol, finalMsg)
+	fmt.Fprintf(out, "\r%s %s          \n", finalSymbol, finalMsg)
 }
 
 func FormatStatus(status string) string {
@@ -52,26 +76,39 @@ func FormatStatus(status string) string {
 	symbol := InfoSymbol
 
 	switch {
-	case strings.Contains(lowerStatus, "secure"):
-		symbol = SuccessSymbol
-	case strings.Contains(lowerStatus, "insecure"):
+	case strings.Contains(lowerStatus, "insecure"), strings.Contains(lowerStatus, "invalid"),
+		strings.Contains(lowerStatus, "untrusted"), strings.Contains(lowerStatus, "mismatch"),
+		strings.Contains(lowerStatus, "expired"), strings.Contains(lowerStatus, "missing"),
+		strings.Contains(lowerStatus, "rejected"), strings.Contains(lowerStatus, "required"),
+		strings.Contains(lowerStatus, "revoked"):
 		symbol = ErrorSymbol
+	case strings.Contains(lowerStatus, "secure"), strings.Contains(lowerStatus, "trusted"),
+		strings.Contains(lowerStatus, "accepted"), strings.HasPrefix(lowerStatus, "good"):
+		symbol = SuccessSymbol
 	case strings.Contains(lowerStatus, "warning"):
 		symbol = WarningSymbol
-	case strings.Contains(lowerStatus, "failed"):
+	case strings.Contains(lowerStatus, "failed"), strings.Contains(lowerStatus, "critical"):
 		symbol = ErrorSymbol
+	case strings.Contains(lowerStatus, "passed"):
+		symbol = SuccessSymbol
 	}
 
 	return fmt.Sprintf("[%s] %s", symbol, status)
 }
 
 func PrintError(msg string) {
-	fmt.Printf("[%s] Error: %s\n", ErrorSymbol, msg)
+	fmt.Fprintf(errOut, "[%s] Error: %s\n", ErrorSymbol, msg)
+}
+
+// PrintWarning writes a warning alongside errors, so machine output formats
+// still show it on stderr.
+func PrintWarning(msg string) {
+	fmt.Fprintf(errOut, "[%s] Warning: %s\n", WarningSymbol, msg)
 }
 
 func PrintInfo(lines ...string) {
 	for _, line := range lines {
-		fmt.Printf("  %s\n", line)
+		fmt.Fprintf(out, "  %s\n", line)
 	}
 }
 
@@ -80,18 +117,18 @@ func FormatKeyValue(key, value string) string {
 }
 
 func PrintCertificateDetails(status, expiry, expiryWarning string) {
-	fmt.Println("\nCertificate Details:")
+	fmt.Fprintln(out, "\nCertificate Details:")
 	PrintInfo(
 		FormatKeyValue("Status", FormatStatus(status)),
 		FormatKeyValue("Valid Until", expiry),
 	)
 	if expiryWarning != "" {
-		fmt.Printf("  Warning: %s\n", FormatStatus(expiryWarning))
+		fmt.Fprintf(out, "  Warning: %s\n", FormatStatus(expiryWarning))
 	}
 }
 
 func PrintScanSummary(host string, portsScanned, secureCount int) {
-	fmt.Println("\nScan Summary:")
+	fmt.Fprintln(out, "\nScan Summary:")
 	ratio := fmt.Sprintf("%d/%d", secureCount, portsScanned)
 	statusSymbol := SuccessSymbol
 	if secureCount < portsScanned {
@@ -108,6 +145,39 @@ func PrintScanSummary(host string, portsScanned, secureCount int) {
 	)
 }
 
+// PrintTargetsSummary summarises a scan of many targets, with how many
+// ended in each status, most common first.
+func PrintTargetsSummary(scanned, connected, secureCount int, statuses map[string]int) {
+	fmt.Fprintln(out, "\nScan Summary:")
+	statusSymbol := SuccessSymbol
+	if secureCount < scanned {
+		statusSymbol = WarningSymbol
+	}
+	if secureCount == 0 && scanned > 0 {
+		statusSymbol = ErrorSymbol
+	}
+
+	PrintInfo(
+		FormatKeyValue("Targets Scanned", strconv.Itoa(scanned)),
+		FormatKeyValue("Connected", fmt.Sprintf("%d/%d", connected, scanned)),
+		FormatKeyValue("Secure Targets", fmt.Sprintf("[%s] %d/%d", statusSymbol, secureCount, scanned)),
+	)
+
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if statuses[names[i]] != statuses[names[j]] {
+			return statuses[names[i]] > statuses[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		PrintInfo(fmt.Sprintf("  %s: %d", FormatStatus(name), statuses[name]))
+	}
+}
+
 func RenderMarkdown(text string) string {
 	r, _ := glamour.NewTermRenderer(
 		glamour.WithStylesFromJSONBytes([]byte(`{
@@ -148,6 +218,6 @@ func CreateTable() table.Writer {
 	style.Box.PaddingRight = "  "
 
 	t.SetStyle(style)
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(out)
 	return t
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of scan, tls and ssh. They are documented in the ReadMe and
+// must not change meaning.
+const (
+	exitPass            = 0 // everything evaluated met the standard
+	exitPolicyViolation = 1 // something evaluated did not meet the standard
+	exitWarning         = 2 // only warnings, with --fail-on warn
+	exitError           = 3 // bad usage, or nothing could be read or reached
+	exitPartialFailure  = 4 // some files or targets could not be read or reached
+)
+
+// Levels of --fail-on, from the strictest.
+const (
+	failOnWarn  = "warn"
+	failOnFail  = "fail"
+	failOnError = "error"
+)
+
+// failOnFlag returns the validated --fail-on level of cmd.
+func failOnFlag(cmd *cobra.Command) (string, error) {
+	level, _ := cmd.Flags().GetString("fail-on")
+	switch level {
+	case failOnWarn, failOnFail, failOnError:
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid --fail-on %q: must be warn, fail or error", level)
+}
+
+// verdict is whether the results behind a row or a target failed the
+// standard, whether any of them raised a warning, and whether part of the
+// evaluation, such as a probe, could not be carried out.
+type verdict struct {
+	insecure   bool
+	warned     bool
+	incomplete bool
+}
+
+// add folds one evaluation into the verdict.
+func (v *verdict) add(result *eval.EvaluationResult) {
+	v.insecure = v.insecure || !strings.HasPrefix(result.Status, "Secure")
+	v.warned = v.warned || hasWarning(result)
+}
+
+// merge folds another verdict into this one.
+func (v *verdict) merge(other verdict) {
+	v.insecure = v.insecure || other.insecure
+	v.warned = v.warned || other.warned
+	v.incomplete = v.incomplete || other.incomplete
+}
+
+// hasWarning reports whether result has a warning finding or is close to
+// expiry.
+func hasWarning(result *eval.EvaluationResult) bool {
+	if result.ExpiryWarning != "" {
+		return true
+	}
+	for _, finding := range result.Findings {
+		if finding.Severity == eval.SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// outcome counts the files or targets of a run by how they fared.
+// Incomplete ones were evaluated, but not every check on them could run.
+type outcome struct {
+	evaluated  int
+	insecure   int
+	warned     int
+	incomplete int
+	errors     int
+}
+
+// add counts one evaluated file or target.
+func (o *outcome) add(v verdict) {
+	o.evaluated++
+	if v.insecure {
+		o.insecure++
+	}
+	if v.warned {
+		o.warned++
+	}
+	if v.incomplete {
+		o.incomplete++
+	}
+}
+
+// exitCode maps the outcome to an exit code. Policy violations fail the
+// run unless failOn is error, and take precedence over files or targets
+// that could not be evaluated, or only in part, so that a violation is never
+// hidden by an unreachable target. Those always fail the run, and warnings
+// only fail it when failOn is warn.
+func (o outcome) exitCode(failOn string) int {
+	switch {
+	case o.errors > 0 && o.evaluated == 0:
+		return exitError
+	case o.insecure > 0 && failOn != failOnError:
+		return exitPolicyViolation
+	case o.errors > 0, o.incomplete > 0:
+		return exitPartialFailure
+	case o.warned > 0 && failOn == failOnWarn:
+		return exitWarning
+	}
+	return exitPass
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/targets"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome outcome
+		failOn  string
+		want    int
+	}{
+		{"Pass", outcome{evaluated: 2}, failOnFail, exitPass},
+		{"PolicyViolation", outcome{evaluated: 2, insecure: 1}, failOnFail, exitPolicyViolation},
+		{"PolicyViolationIgnored", outcome{evaluated: 2, insecure: 1}, failOnError, exitPass},
+		{"Warning", outcome{evaluated: 2, warned: 1}, failOnWarn, exitWarning},
+		{"WarningIgnored", outcome{evaluated: 2, warned: 1}, failOnFail, exitPass},
+		{"NothingReached", outcome{errors: 2}, failOnFail, exitError},
+		{"SomeUnreached", outcome{evaluated: 1, errors: 1}, failOnFail, exitPartialFailure},
+		{"SomeUnreachedWarning", outcome{evaluated: 1, warned: 1, errors: 1}, failOnWarn, exitPartialFailure},
+		{"PolicyViolationSomeUnreached", outcome{evaluated: 1, insecure: 1, errors: 1}, failOnFail, exitPolicyViolation},
+		{"PolicyViolationSomeUnreachedFailOnError", outcome{evaluated: 1, insecure: 1, errors: 1}, failOnError, exitPartialFailure},
+		{"Incomplete", outcome{evaluated: 1, incomplete: 1}, failOnFail, exitPartialFailure},
+		{"PolicyViolationIncomplete", outcome{evaluated: 1, insecure: 1, incomplete: 1}, failOnFail, exitPolicyViolation},
+		{"IncompleteFailOnError", outcome{evaluated: 1, incomplete: 1}, failOnError, exitPartialFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.outcome.exitCode(tt.failOn); got != tt.want {
+				t.Errorf("exitCode(%q) = %d, want %d", tt.failOn, got, tt.want)
+			}
+		})
+	}
+}
+
+// selfSignedCertificate returns a P-256 key and a self-signed certificate for
+// 127.0.0.1 that expires at notAfter.
+func selfSignedCertificate(t *testing.T, notAfter time.Time) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return key, der
+}
+
+// TestExitCodeExpiryWarning scans a PEM certificate that expires in three
+// days with --check-expiry, which only fails the run with --fail-on warn.
+func TestExitCodeExpiryWarning(t *testing.T) {
+	_, der := selfSignedCertificate(t, time.Now().Add(3*24*time.Hour))
+	file := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	cfg, err := config.NewLayeredConfig([]config.Layer{config.EmbeddedLayer()}, "NIST")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	_, result, record := scanFile(file, "NIST", cfg, true, eval.Options{})
+	if result == nil {
+		t.Fatalf("Expected an evaluation, got %+v", record)
+	}
+	if result.Expiry == "" || result.ExpiryWarning == "" {
+		t.Fatalf("Expected an expiry warning, got expiry %q and warning %q", result.Expiry, result.ExpiryWarning)
+	}
+	var v verdict
+	v.add(result)
+	var o outcome
+	o.add(v)
+
+	for failOn, want := range map[string]int{failOnWarn: exitWarning, failOnFail: exitPass, failOnError: exitPass} {
+		if got := o.exitCode(failOn); got != want {
+			t.Errorf("exitCode(%q) = %d, want %d", failOn, got, want)
+		}
+	}
+}
+
+// TestExitCodeEnumerationFailed scans a server that completes one handshake
+// and then stops listening, so every enumeration probe fails to connect.
+func TestExitCodeEnumerationFailed(t *testing.T) {
+	key, der := selfSignedCertificate(t, time.Now().Add(time.Hour))
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		conn, err := listener.Accept()
+		listener.Close()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		conn.(*tls.Conn).Handshake()
+	}()
+
+	cfg, err := config.NewLayeredConfig([]config.Layer{config.EmbeddedLayer()}, "NIST")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	scan := &tlsScan{
+		cfg:       cfg,
+		roots:     roots,
+		startTLS:  "none",
+		timeout:   2 * time.Second,
+		limiter:   targets.NewLimiter(0),
+		enumerate: true,
+	}
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	result := scan.scanTarget(targets.Target{Host: host, Port: port})
+	var o outcome
+	result.tally(&o)
+
+	if o.evaluated != 1 || o.insecure != 0 || o.incomplete != 1 || o.errors != 0 {
+		t.Fatalf("Expected one incomplete target, got %+v", o)
+	}
+	for _, failOn := range []string{failOnFail, failOnError} {
+		if got := o.exitCode(failOn); got != exitPartialFailure {
+			t.Errorf("exitCode(%q) = %d, want %d", failOn, got, exitPartialFailure)
+		}
+	}
+}
@@ -1,20 +1,21 @@
 package main
 
 import (
-	"crypto/tls"
+	"encoding/pem"
 	"fmt"
-	"net"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/internal/blocklist"
 	"github.com/Horiodino/key-length/internal/config"
 	"github.com/Horiodino/key-length/internal/eval"
 	"github.com/Horiodino/key-length/internal/parse"
+	"github.com/Horiodino/key-length/internal/report"
+	"github.com/Horiodino/key-length/internal/rsa"
 	"github.com/Horiodino/key-length/internal/types"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 )
 
@@ -27,272 +28,339 @@ var rootCmd = &cobra.Command{
 }
 
 var scanCmd = &cobra.Command{
-	Use:   "scan [file]",
-	Short: "Scan a key or certificate file for security evaluation",
-	Long:  `Scan evaluates the cryptographic strength of a key or certificate file based on its length and a selected standard.`,
-	Args:  cobra.ExactArgs(1),
+	Use:   "scan [file...]",
+	Short: "Scan key or certificate files for security evaluation",
+	Long: `Scan evaluates the cryptographic strength of key or certificate files based on their length and a selected standard.
+
+When several files are given, the RSA moduli of all of them are also checked against each other for shared prime factors.
+
+The exit code is 0 when every file meets the standard, 1 when any does not,
+2 when --fail-on is warn and there are only warnings, 3 on bad usage or when
+no file could be read, and 4 when only some files could be read. A policy
+violation exits 1 even when some files could not be read.
+
+With --output json, yaml or csv, the results are written to stdout in a
+versioned schema instead, and errors to stderr.`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		file := args[0]
 		standard, _ := cmd.Flags().GetString("standard")
 		checkExpiry, _ := cmd.Flags().GetBool("check-expiry")
-
-		s := display.NewSpinner("Loading configuration")
-		cfg, err := config.NewConfig("data/standards.json", standard)
+		failOn, err := failOnFlag(cmd)
 		if err != nil {
-			display.StopSpinner(s, false)
-			display.PrintError(fmt.Sprintf("Error loading config: %v", err))
-			os.Exit(1)
+			display.PrintError(err.Error())
+			os.Exit(exitError)
 		}
-		display.StopSpinner(s, true)
-
-		s = display.NewSpinner("Reading and parsing file")
-		data, err := os.ReadFile(file)
+		format, err := outputFormat(cmd)
 		if err != nil {
-			display.StopSpinner(s, false)
-			display.PrintError(fmt.Sprintf("Error reading file '%s': %v", file, err))
-			os.Exit(1)
+			display.PrintError(err.Error())
+			os.Exit(exitError)
 		}
 
-		parsedKey, err := parse.ParseData(data)
+		cfg, err := loadConfig(cmd, standard)
 		if err != nil {
-			display.StopSpinner(s, false)
-			display.PrintError(fmt.Sprintf("Error parsing file '%s': %v", file, err))
-			return
+			display.PrintError(fmt.Sprintf("Error loading config: %v", err))
+			os.Exit(exitError)
 		}
-		display.StopSpinner(s, true)
-
-		display.PrintSection("Analysis Results", "")
-		display.PrintInfo(
-			display.FormatKeyValue("File", display.RenderMarkdown(fmt.Sprintf("`%s`", file))),
-			display.FormatKeyValue("Standard", display.RenderMarkdown(fmt.Sprintf("`%s`", standard))),
-		)
-		fmt.Println()
-
-		var certData []byte
-		if checkExpiry {
-			certData = data
+		spinnerActive := hasBlocklists(cmd)
+		var s spinner.Model
+		if spinnerActive {
+			s = display.NewSpinner("Loading blocklists")
 		}
-		result := eval.EvaluateKey(parsedKey.Key.(types.KeyLengthEvaluator), cfg, certData)
-
-		if result == nil {
-			display.PrintError("Evaluation failed: Result was nil.")
-			return
+		opts, err := loadEvalOptions(cmd)
+		if err != nil {
+			if spinnerActive {
+				display.StopSpinner(s, false)
+			}
+			display.PrintError(fmt.Sprintf("Error loading blocklist: %v", err))
+			os.Exit(exitError)
+		}
+		if spinnerActive {
+			display.StopSpinner(s, true)
 		}
 
-		t := display.CreateTable()
-		t.AppendHeader(table.Row{"Property", "Value", "Status"})
-
-		t.AppendRow(table.Row{
-			"Algorithm",
-			result.Algorithm,
-			display.FormatStatus(result.Status),
-		})
-
-		t.AppendRow(table.Row{
-			"Key Length",
-			fmt.Sprintf("%d bits", result.Length),
-			display.FormatStatus(result.Status),
-		})
-
-		expiryStatus := result.Status
-		if checkExpiry && result.Expiry != "" {
-			if result.ExpiryWarning != "" {
-				expiryStatus = result.ExpiryWarning
+		var moduli []rsa.Modulus
+		// A key and its own certificate hold the same public key, so only the
+		// first file with each SPKI goes into the shared prime analysis, and
+		// aliases lists the later ones under it.
+		firstFile := map[string]string{}
+		aliases := map[string][]string{}
+		var o outcome
+		rep := report.New("scan", standard)
+		for _, file := range args {
+			parsedKey, result, record := scanFile(file, standard, cfg, checkExpiry, opts)
+			rep.Results = append(rep.Results, record)
+			if result == nil {
+				o.errors++
+			} else {
+				var v verdict
+				v.add(result)
+				o.add(v)
+			}
+			if parsedKey == nil {
+				continue
+			}
+			if rsaKey, ok := parsedKey.Key.(*rsa.RSAKey); ok {
+				if fingerprint, err := parsedKey.Fingerprint(); err == nil {
+					if first, seen := firstFile[fingerprint]; seen {
+						aliases[first] = append(aliases[first], file)
+						continue
+					}
+					firstFile[fingerprint] = file
+				}
+				moduli = append(moduli, rsa.Modulus{Asset: file, N: rsaKey.Modulus()})
 			}
-			t.AppendRow(table.Row{
-				"Expiry",
-				result.Expiry,
-				display.FormatStatus(expiryStatus),
-			})
 		}
 
-		t.SetColumnConfigs([]table.ColumnConfig{
-			{Number: 1, AutoMerge: true, WidthMax: 20},
-			{Number: 2, WidthMax: 30},
-			{Number: 3, WidthMax: 30},
-		})
-
-		t.Render()
-
-		if checkExpiry && result.Expiry != "" {
-			display.PrintCertificateDetails(result.Status, result.Expiry, result.ExpiryWarning)
+		if len(args) > 1 {
+			shared, records := printSharedFactors(moduli, aliases)
+			rep.Results = append(rep.Results, records...)
+			if shared.insecure {
+				o.insecure++
+			}
+			if shared.warned {
+				o.warned++
+			}
 		}
+		finish(rep, format, failOn, o)
 	},
 }
 
-var tlsCmd = &cobra.Command{
-	Use:   "tls [host]",
-	Short: "Evaluate the TLS certificate of a remote server",
-	Long:  `TLS connects to a remote server and evaluates its certificate security.`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		input := args[0]
-		standard, _ := cmd.Flags().GetString("standard")
-		portsStr, _ := cmd.Flags().GetString("ports")
-		checkExpiry, _ := cmd.Flags().GetBool("check-expiry")
-		timeoutStr, _ := cmd.Flags().GetString("timeout")
+// scanFile evaluates a single file and prints its results. It returns the
+// parsed key, its evaluation and its report record; the key is nil if the
+// file could not be read or parsed, and the evaluation nil if it could not
+// be evaluated.
+func scanFile(file, standard string, cfg *config.Config, checkExpiry bool, opts eval.Options) (*parse.ParsedKey, *eval.EvaluationResult, report.Result) {
+	record := report.Result{Source: file}
+	s := display.NewSpinner("Reading and parsing file")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		display.StopSpinner(s, false)
+		display.PrintError(fmt.Sprintf("Error reading file '%s': %v", file, err))
+		return nil, nil, record.Failed(report.KindFile, "Read Failed", err)
+	}
 
-		input = strings.TrimPrefix(input, "https://")
-		input = strings.TrimPrefix(input, "http://")
-		if strings.Contains(input, "/") {
-			input = strings.Split(input, "/")[0]
+	parsedKey, err := parse.ParseData(data)
+	if err != nil {
+		display.StopSpinner(s, false)
+		display.PrintError(fmt.Sprintf("Error parsing file '%s': %v", file, err))
+		return nil, nil, record.Failed(report.KindFile, "Parse Failed", err)
+	}
+	display.StopSpinner(s, true)
+
+	display.PrintSection("Analysis Results", "")
+	display.PrintInfo(
+		display.FormatKeyValue("File", display.RenderMarkdown(fmt.Sprintf("`%s`", file))),
+		display.FormatKeyValue("Standard", display.RenderMarkdown(fmt.Sprintf("`%s`", standard))),
+	)
+	if source := cfg.GetStandard().Source; source != "" {
+		display.PrintInfo(display.FormatKeyValue("Source", source))
+	}
+	display.Println()
+
+	var certData []byte
+	if checkExpiry {
+		// The expiry check parses DER, so unwrap a PEM certificate first.
+		certData = data
+		if block, _ := pem.Decode(data); block != nil {
+			certData = block.Bytes
 		}
+	}
+	result := eval.EvaluateKeyWithOptions(parsedKey.Key.(types.KeyLengthEvaluator), cfg, certData, opts)
 
-		ports := []string{"443"}
-		if portsStr != "" {
-			ports = []string{}
-			for _, p := range strings.Split(portsStr, ",") {
-				p = strings.TrimSpace(p)
-				if p != "" {
-					ports = append(ports, p)
-				}
-			}
-		}
-		if len(ports) == 0 {
-			display.PrintError("No valid ports specified.")
-			os.Exit(1)
-		}
+	if result == nil {
+		display.PrintError("Evaluation failed: Result was nil.")
+		return parsedKey, nil, record.Failed(report.KindFile, "Evaluation Failed", nil)
+	}
 
-		timeout := 5 * time.Second
-		if timeoutStr != "" {
-			dur, err := time.ParseDuration(timeoutStr)
-			if err != nil {
-				display.PrintError(fmt.Sprintf("Invalid timeout format '%s': %v. Using default 5s.", timeoutStr, err))
-			} else {
-				timeout = dur
-			}
-		}
+	kind := report.KindKey
+	if holder, ok := parsedKey.Key.(types.CertificateHolder); ok && holder.Certificate() != nil {
+		kind = report.KindCertificate
+		record.Subject = holder.Certificate().Subject.String()
+	}
+	record = record.Evaluated(kind, "", result)
 
-		display.PrintSection("TLS Analysis", "")
-		display.PrintInfo(
-			display.FormatKeyValue("Host", display.RenderMarkdown(fmt.Sprintf("`%s`", input))),
-			display.FormatKeyValue("Ports", display.RenderMarkdown(fmt.Sprintf("`%s`", strings.Join(ports, ", ")))),
-			display.FormatKeyValue("Timeout", display.RenderMarkdown(fmt.Sprintf("`%s`", timeout))),
-			display.FormatKeyValue("Standard", display.RenderMarkdown(fmt.Sprintf("`%s`", standard))),
-		)
-		fmt.Println()
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Property", "Value", "Status"})
 
-		cfg, err := config.NewConfig("data/standards.json", standard)
-		if err != nil {
-			display.PrintError(fmt.Sprintf("Config error: %v", err))
-			os.Exit(1)
-		}
+	t.AppendRow(table.Row{
+		"Algorithm",
+		result.Algorithm,
+		display.FormatStatus(result.Status),
+	})
 
-		t := display.CreateTable()
-		t.AppendHeader(table.Row{"Port", "Status", "Algorithm", "Key Length", "Details"})
+	t.AppendRow(table.Row{
+		"Key Length",
+		fmt.Sprintf("%d bits", result.Length),
+		display.FormatStatus(result.Status),
+	})
+
+	if result.Curve != "" {
+		t.AppendRow(table.Row{
+			"Curve",
+			result.Curve,
+			display.FormatStatus(checkStatus(result, "curve")),
+		})
+	}
 
-		secureCount := 0
-		totalResults := 0
+	if result.SignatureAlgorithm != "" {
+		t.AppendRow(table.Row{
+			"Signature",
+			result.SignatureAlgorithm,
+			display.FormatStatus(checkStatus(result, "signature")),
+		})
+	}
 
-		spinnerActive := false
-		var s spinner.Model
-		if len(ports) > 1 {
-			s = display.NewSpinner(fmt.Sprintf("Checking %d ports", len(ports)))
-			spinnerActive = true
-		} else if len(ports) == 1 {
-			fmt.Printf("[%s] Checking %s:%s...\n", display.InfoSymbol, input, ports[0])
+	for _, finding := range result.Findings {
+		t.AppendRow(table.Row{
+			"Finding",
+			finding.Message,
+			display.FormatStatus(finding.Severity),
+		})
+	}
+
+	expiryStatus := result.Status
+	if checkExpiry && result.Expiry != "" {
+		if result.ExpiryWarning != "" {
+			expiryStatus = result.ExpiryWarning
 		}
+		t.AppendRow(table.Row{
+			"Expiry",
+			result.Expiry,
+			display.FormatStatus(expiryStatus),
+		})
+	}
 
-		for _, port := range ports {
-			hostPort := net.JoinHostPort(input, port)
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 20},
+		{Number: 2, WidthMax: 30, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 3, WidthMax: 30},
+	})
 
-			conn, err := tls.DialWithDialer(
-				&net.Dialer{Timeout: timeout},
-				"tcp",
-				hostPort,
-				&tls.Config{
-					ServerName:         input,
-					InsecureSkipVerify: true,
-				},
-			)
+	t.Render()
 
-			row := table.Row{port, "", "", "", ""}
+	if checkExpiry && result.Expiry != "" {
+		display.PrintCertificateDetails(result.Status, result.Expiry, result.ExpiryWarning)
+	}
+	return parsedKey, result, record
+}
 
-			if err != nil {
-				row[1] = display.FormatStatus("Connection Failed")
-				row[4] = fmt.Sprintf("Error: %v", err)
-			} else {
-				if len(conn.ConnectionState().PeerCertificates) == 0 {
-					row[1] = display.FormatStatus("No Certificate")
-					row[4] = "Server did not present a certificate."
-				} else {
-					cert := conn.ConnectionState().PeerCertificates[0]
-					parsedKey, pErr := parse.ParseData(cert.Raw)
-
-					if pErr != nil {
-						row[1] = display.FormatStatus("Parsing Failed")
-						row[4] = fmt.Sprintf("Cert parse error: %v", pErr)
-					} else {
-						var certData []byte
-						if checkExpiry {
-							certData = cert.Raw
-						}
-						result := eval.EvaluateKey(parsedKey.Key.(types.KeyLengthEvaluator), cfg, certData)
-
-						row[1] = display.FormatStatus(result.Status)
-						row[2] = result.Algorithm
-						row[3] = fmt.Sprintf("%d bits", result.Length)
-
-						details := []string{}
-						if checkExpiry {
-							expiryDetail := fmt.Sprintf("Expires: %s", result.Expiry)
-							if result.ExpiryWarning != "" {
-								expiryDetail += fmt.Sprintf(" (%s)", display.FormatStatus(result.ExpiryWarning))
-							}
-							details = append(details, expiryDetail)
-						}
-						row[4] = strings.Join(details, "; ")
-						if row[4] == "" {
-							row[4] = "-"
-						}
-
-						if strings.Contains(result.Status, "Secure") {
-							secureCount++
-						}
-						totalResults++
-					}
-				}
-				conn.Close()
+// printSharedFactors runs batch GCD over every distinct RSA key of the scan
+// and lists the pairs of files whose keys can be factored, including the
+// aliases of each file. A shared prime is insecure, and a shared modulus
+// under a different public key a warning. It returns the verdict and a
+// report record for each pair.
+func printSharedFactors(moduli []rsa.Modulus, aliases map[string][]string) (verdict, []report.Result) {
+	display.PrintSection("Shared Prime Analysis", "")
+	display.PrintInfo(display.FormatKeyValue("RSA Keys", fmt.Sprintf("%d", len(moduli))))
+	display.Println()
+
+	pairs := rsa.BatchGCD(moduli)
+	if len(pairs) == 0 {
+		display.Printf("[%s] No RSA keys share a prime factor.\n", display.SuccessSymbol)
+		return verdict{}, nil
+	}
+
+	var v verdict
+	var records []report.Result
+
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Asset", "Asset", "Status", "Details"})
+	for _, pair := range pairs {
+		severity := types.SeverityCritical
+		details := fmt.Sprintf("Shared %d-bit prime; both keys can be factored", pair.Factor.BitLen())
+		if pair.Duplicate {
+			severity = types.SeverityWarning
+			details = "Same modulus with a different public key; either private key factors both"
+			v.warned = true
+		} else {
+			v.insecure = true
+		}
+		for _, a := range append([]string{pair.AssetA}, aliases[pair.AssetA]...) {
+			for _, b := range append([]string{pair.AssetB}, aliases[pair.AssetB]...) {
+				t.AppendRow(table.Row{a, b, display.FormatStatus(severity), details})
+				records = append(records, report.Result{Source: a}.Noted(report.KindSharedPrime, b, severity, details))
 			}
-			t.AppendRow(row)
 		}
+	}
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, WidthMax: 30, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 2, WidthMax: 30, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 4, WidthMax: 40, WidthMaxEnforcer: text.WrapSoft},
+	})
+	t.Render()
+	return v, records
+}
 
-		if spinnerActive {
-			display.StopSpinner(s, true)
+// checkStatus returns the severity of the named check's finding, or "Passed".
+func checkStatus(result *eval.EvaluationResult, check string) string {
+	for _, finding := range result.Findings {
+		if finding.Check == check {
+			return finding.Severity
 		}
+	}
+	return "Passed"
+}
 
-		t.SetColumnConfigs([]table.ColumnConfig{
-			{Number: 1, WidthMax: 8},
-			{Number: 2, WidthMax: 25},
-			{Number: 3, WidthMax: 15},
-			{Number: 4, WidthMax: 12},
-			{Number: 5, WidthMax: 45},
-		})
+// hasBlocklists reports whether any blocklist file was given to cmd.
+func hasBlocklists(cmd *cobra.Command) bool {
+	debianFiles, _ := cmd.Flags().GetStringSlice("debian-blocklist")
+	keyFiles, _ := cmd.Flags().GetStringSlice("key-blocklist")
+	return len(debianFiles) > 0 || len(keyFiles) > 0
+}
 
-		if totalResults > 0 || len(ports) > totalResults {
-			t.Render()
-			display.PrintScanSummary(input, len(ports), secureCount)
-		} else if len(ports) == 1 && totalResults == 0 {
-		} else if len(ports) > 1 && totalResults == 0 {
-			display.PrintError("No TLS connections could be successfully evaluated.")
+func loadEvalOptions(cmd *cobra.Command) (eval.Options, error) {
+	var opts eval.Options
+	debianFiles, _ := cmd.Flags().GetStringSlice("debian-blocklist")
+	if len(debianFiles) > 0 {
+		debian, err := rsa.LoadDebianBlocklist(debianFiles...)
+		if err != nil {
+			return opts, err
 		}
-	},
+		opts.DebianBlocklist = debian
+	}
+	keyFiles, _ := cmd.Flags().GetStringSlice("key-blocklist")
+	if len(keyFiles) > 0 {
+		keys, err := blocklist.Load(keyFiles...)
+		if err != nil {
+			return opts, err
+		}
+		opts.KeyBlocklist = keys
+	}
+	return opts, nil
+}
+
+// loadConfig merges every configuration source and prints the schema
+// warnings raised while loading them.
+func loadConfig(cmd *cobra.Command, standard string) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	layers, err := config.DiscoverLayers(configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.NewLayeredConfig(layers, standard)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cfg.Warnings() {
+		display.PrintWarning(warning)
+	}
+	return cfg, nil
 }
 
 func init() {
-	scanCmd.Flags().StringP("standard", "s", "NIST", "Security standard (e.g., NIST, BSI)")
+	rootCmd.PersistentFlags().String("config", "", "Standards file layered over the built-in and discovered configuration")
+
+	scanCmd.Flags().StringP("standard", "s", "NIST", "Security standard (see 'keylength-check standards list')")
 	scanCmd.Flags().BoolP("check-expiry", "e", false, "Check certificate expiry date")
+	scanCmd.Flags().StringSlice("debian-blocklist", nil, "Debian weak-key blocklist files (openssl-blacklist format)")
+	scanCmd.Flags().StringSlice("key-blocklist", nil, "Compromised-key blocklist files of SPKI SHA-256 fingerprints")
+	scanCmd.Flags().String("fail-on", failOnFail, "Lowest level that fails the exit code: warn, fail or error")
+	scanCmd.Flags().StringP("output", "o", report.FormatText, "Output format: text, json, yaml or csv")
 	rootCmd.AddCommand(scanCmd)
-
-	tlsCmd.Flags().StringP("standard", "s", "NIST", "Security standard (e.g., NIST, BSI)")
-	tlsCmd.Flags().StringP("ports", "p", "443", "Comma-separated ports (e.g., 443,8443)")
-	tlsCmd.Flags().BoolP("check-expiry", "e", false, "Check certificate expiry date")
-	tlsCmd.Flags().StringP("timeout", "t", "5s", "Connection timeout (e.g., 3s, 10s)")
-	rootCmd.AddCommand(tlsCmd)
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// outputFormat returns the validated --output format of cmd. For machine
+// formats, the spinners, tables and styling are discarded so that only the
+// report reaches stdout, and errors go to stderr.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	value, _ := cmd.Flags().GetString("output")
+	format, err := report.ParseFormat(value)
+	if err != nil {
+		return "", err
+	}
+	if format != report.FormatText {
+		display.SetOutput(io.Discard, os.Stderr)
+	}
+	return format, nil
+}
+
+// finish writes the report in a machine format, and exits with the code of
+// the outcome.
+func finish(rep *report.Report, format, failOn string, o outcome) {
+	code := o.exitCode(failOn)
+	if format != report.FormatText {
+		rep.Summary = report.Summary{
+			Evaluated:  o.evaluated,
+			Insecure:   o.insecure,
+			Warned:     o.warned,
+			Incomplete: o.incomplete,
+			Errors:     o.errors,
+			ExitCode:   code,
+		}
+		if err := report.Write(os.Stdout, format, rep); err != nil {
+			display.PrintError(fmt.Sprintf("Error writing report: %v", err))
+			os.Exit(exitError)
+		}
+	}
+	os.Exit(code)
+}
+
+// chainRecords mirrors chainRows: the verdict of a chain of more than one
+// certificate, then every certificate, leaf first.
+func chainRecords(record report.Result, chain *eval.ChainResult) []report.Result {
+	var records []report.Result
+	if len(chain.Links) > 1 {
+		weakest := chain.Links[chain.Weakest]
+		records = append(records, record.Noted(report.KindChain, fmt.Sprintf("Chain (%d)", len(chain.Links)), chain.Status,
+			fmt.Sprintf("Weakest link: %s (%s)", weakest.Position, weakest.Subject)))
+	}
+	for _, link := range chain.Links {
+		certificate := record
+		certificate.Name, certificate.Subject = link.Position, link.Subject
+		if link.Err != nil {
+			records = append(records, certificate.Failed(report.KindCertificate, "Parsing Failed", link.Err))
+			continue
+		}
+		records = append(records, certificate.Evaluated(report.KindCertificate, link.Position, link.Result))
+	}
+	return records
+}
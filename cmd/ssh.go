@@ -0,0 +1,432 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/proxy"
+	"github.com/Horiodino/key-length/internal/report"
+	"github.com/Horiodino/key-length/internal/sshprobe"
+	"github.com/Horiodino/key-length/internal/targets"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh [host]",
+	Short: "Evaluate the host keys and algorithms of a remote SSH server",
+	Long: `SSH runs the SSH transport handshake with a remote server up to the key
+exchange, without authenticating, and evaluates what it offers.
+
+Every host key is fetched by completing a key exchange for each host key
+algorithm the server lists, and is evaluated like any other key of its type:
+RSA modulus size, ECDSA curve, Ed25519 against the ECC threshold. The key
+exchange methods are evaluated against the ECC and DH thresholds, with the
+smallest group a group exchange server will use probed for, and the ciphers
+and MACs against the Symmetric threshold. A port is only counted as secure
+when every host key and every algorithm it offers meets the standard.
+
+Each port takes a connection per host key algorithm and group exchange
+method, so ports are scanned --concurrency at a time, and --rate-limit caps
+the connections per second made to the host.
+
+The exit code is 0 when every port meets the standard, 1 when any does not,
+2 when --fail-on is warn and there are only warnings, 3 on bad usage or when
+no port could be reached, and 4 when only some ports could be reached or a
+host key probe failed. A policy violation exits 1 even when some ports could
+not be reached.
+
+With --output json, yaml or csv, the results are written to stdout in a
+versioned schema instead, and errors to stderr.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		standard, _ := cmd.Flags().GetString("standard")
+		portsStr, _ := cmd.Flags().GetString("ports")
+		timeoutStr, _ := cmd.Flags().GetString("timeout")
+		proxyURL, _ := cmd.Flags().GetString("proxy")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+		failOn, err := failOnFlag(cmd)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+		rep := report.New("ssh", standard)
+
+		input := strings.TrimPrefix(args[0], "ssh://")
+		if i := strings.LastIndex(input, "@"); i >= 0 {
+			input = input[i+1:]
+		}
+		if _, err := netip.ParsePrefix(input); err == nil {
+			display.PrintError("ssh takes a single host, not a CIDR range.")
+			os.Exit(exitError)
+		}
+
+		ports, err := targets.ParsePorts(portsStr)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Invalid ports: %v", err))
+			os.Exit(exitError)
+		}
+		if len(ports) == 0 {
+			display.PrintError("No valid ports specified.")
+			os.Exit(exitError)
+		}
+		if concurrency < 1 {
+			display.PrintError("--concurrency must be at least 1.")
+			os.Exit(exitError)
+		}
+		list, err := targets.Expand(input, ports)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+
+		timeout := 5 * time.Second
+		if timeoutStr != "" {
+			dur, err := time.ParseDuration(timeoutStr)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("Invalid timeout format '%s': %v. Using default 5s.", timeoutStr, err))
+			} else {
+				timeout = dur
+			}
+		}
+
+		envProxy, noProxy := proxy.FromEnvironment()
+		switch proxyURL {
+		case "":
+			proxyURL = envProxy
+		case "none":
+			proxyURL = ""
+		}
+		dialer, err := proxy.New(proxyURL, noProxy, timeout)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+
+		host := list[0].Host
+		ports = ports[:0]
+		for _, target := range list {
+			ports = append(ports, target.Port)
+		}
+
+		display.PrintSection("SSH Analysis", "")
+		display.PrintInfo(
+			display.FormatKeyValue("Host", display.RenderMarkdown(fmt.Sprintf("`%s`", host))),
+			display.FormatKeyValue("Ports", display.RenderMarkdown(fmt.Sprintf("`%s`", strings.Join(ports, ", ")))),
+		)
+		if len(list) > 1 {
+			display.PrintInfo(display.FormatKeyValue("Concurrency", display.RenderMarkdown(fmt.Sprintf("`%d`", concurrency))))
+		}
+		if dialer != nil {
+			display.PrintInfo(display.FormatKeyValue("Proxy", display.RenderMarkdown(fmt.Sprintf("`%s`", dialer.URL.Redacted()))))
+		}
+		display.PrintInfo(
+			display.FormatKeyValue("Timeout", display.RenderMarkdown(fmt.Sprintf("`%s`", timeout))),
+			display.FormatKeyValue("Standard", display.RenderMarkdown(fmt.Sprintf("`%s`", standard))),
+		)
+		display.Println()
+
+		cfg, err := loadConfig(cmd, standard)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+		if source := cfg.GetStandard().Source; source != "" {
+			display.PrintInfo(display.FormatKeyValue("Source", source))
+			display.Println()
+		}
+		opts, err := loadEvalOptions(cmd)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Blocklist error: %v", err))
+			os.Exit(exitError)
+		}
+
+		scan := &sshScan{cfg: cfg, opts: opts, timeout: timeout, limiter: targets.NewLimiter(rateLimit), proxy: dialer}
+
+		spinnerActive := false
+		var s spinner.Model
+		if len(list) > 1 {
+			s = display.NewSpinner(fmt.Sprintf("Checking %d ports", len(list)))
+			spinnerActive = true
+		} else {
+			display.Printf("[%s] Checking %s...\n", display.InfoSymbol, list[0].Address())
+		}
+
+		results := make([]*sshResult, len(list))
+		targets.Run(list, concurrency, scan.scanPort, func(i int, result *sshResult) {
+			results[i] = result
+		})
+
+		if spinnerActive {
+			display.StopSpinner(s, true)
+		}
+
+		hostKeys, algorithms := newHostKeyTable(), newSSHAlgorithmTable()
+		secureCount, connected := 0, 0
+		var o outcome
+		for _, result := range results {
+			hostKeys.AppendRows(result.hostKeys)
+			algorithms.AppendRows(result.algorithms)
+			rep.Results = append(rep.Results, result.records...)
+			if !result.connected {
+				o.errors++
+				continue
+			}
+			connected++
+			o.add(result.verdict)
+			if !result.verdict.insecure {
+				secureCount++
+			}
+		}
+
+		hostKeys.Render()
+		if connected > 0 {
+			display.PrintSection("Key Exchange, Ciphers and MACs", "")
+			algorithms.Render()
+		}
+		display.PrintScanSummary(host, len(list), secureCount)
+		finish(rep, format, failOn, o)
+	},
+}
+
+// sshScan holds what every port of an ssh run is evaluated with.
+type sshScan struct {
+	cfg     *config.Config
+	opts    eval.Options
+	timeout time.Duration
+	limiter *targets.Limiter
+	proxy   *proxy.Dialer
+}
+
+// dial opens a connection, through the proxy if one is set, once the rate
+// limit for the address's host allows.
+func (scan *sshScan) dial(network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	scan.limiter.Wait(host)
+	if scan.proxy != nil {
+		return scan.proxy.Dial(network, address)
+	}
+	return net.DialTimeout(network, address, scan.timeout)
+}
+
+// sshResult holds the rows and records of one port and the verdict on it.
+type sshResult struct {
+	hostKeys   []table.Row
+	algorithms []table.Row
+	records    []report.Result
+	connected  bool
+	verdict    verdict
+}
+
+// scanPort lists the algorithms one port offers, fetches its host keys and
+// evaluates all of them.
+func (scan *sshScan) scanPort(target targets.Target) *sshResult {
+	result := &sshResult{}
+	port := target.Port
+	prober := &sshprobe.Prober{Address: target.Address(), Timeout: scan.timeout, Dial: scan.dial}
+	record := report.Result{Source: target.Host, Port: port}
+
+	offered, err := prober.Algorithms()
+	if err != nil {
+		result.hostKeys = append(result.hostKeys, table.Row{port, "-", display.FormatStatus("Connection Failed"), "", "", "", fmt.Sprintf("Error: %v", err)})
+		result.records = append(result.records, record.Failed(report.KindConnection, "Connection Failed", err))
+		return result
+	}
+	result.connected = true
+	var v verdict
+
+	keys, skipped := prober.HostKeys(offered.HostKeys)
+	for _, key := range keys {
+		row, hostKey, keyVerdict := scan.hostKeyRow(port, record, key)
+		result.hostKeys = append(result.hostKeys, row)
+		result.records = append(result.records, hostKey)
+		v.merge(keyVerdict)
+	}
+	for _, skip := range skipped {
+		if skip.Err != nil {
+			result.hostKeys = append(result.hostKeys, table.Row{port, skip.Algorithm, display.FormatStatus("Probe Failed"), "", "", "", fmt.Sprintf("Error: %v", skip.Err)})
+			failed := record
+			failed.Name = skip.Algorithm
+			result.records = append(result.records, failed.Failed(report.KindHostKey, "Probe Failed", skip.Err))
+			v.incomplete = true
+			continue
+		}
+		result.hostKeys = append(result.hostKeys, table.Row{port, skip.Algorithm, display.FormatStatus("Not Probed"), "", "", "", "Host key algorithm is not supported by the prober"})
+		result.records = append(result.records, record.Noted(report.KindHostKey, skip.Algorithm, "Not Probed", "Host key algorithm is not supported by the prober"))
+	}
+
+	protocol := eval.EvaluateSSHProtocol(offered, scan.cfg)
+	v.add(protocol)
+	result.algorithms = append(result.algorithms, table.Row{port, "Protocol", offered.Banner, display.FormatStatus(protocol.Status), protocol.Algorithm, "", findingDetails(protocol)})
+	result.records = append(result.records, record.Evaluated(report.KindProtocol, offered.Banner, protocol))
+
+	for _, name := range offered.KeyExchanges {
+		kex, ok := sshprobe.KeyExchangeByName(name)
+		if !ok {
+			result.algorithms = append(result.algorithms, unknownAlgorithmRow(port, "Key Exchange", name))
+			result.records = append(result.records, unknownAlgorithmRecord(record, report.KindKeyExchange, name))
+			continue
+		}
+		var details []string
+		if kex.Kind == sshprobe.KindGEX {
+			prime, err := prober.GroupExchange(offered, name)
+			if err != nil {
+				details = append(details, fmt.Sprintf("Group probe failed: %v", err))
+			} else {
+				kex.Bits = prime.BitLen()
+				details = append(details, "Smallest group offered")
+			}
+		}
+		evaluated := eval.EvaluateSSHKeyExchange(kex, scan.cfg)
+		v.add(evaluated)
+		result.algorithms = append(result.algorithms, algorithmRow(port, "Key Exchange", name, evaluated, details))
+		result.records = append(result.records, algorithmRecord(record, report.KindKeyExchange, name, evaluated, details))
+	}
+
+	for _, name := range offered.Ciphers {
+		cipher, ok := sshprobe.CipherByName(name)
+		if !ok {
+			result.algorithms = append(result.algorithms, unknownAlgorithmRow(port, "Cipher", name))
+			result.records = append(result.records, unknownAlgorithmRecord(record, report.KindCipher, name))
+			continue
+		}
+		evaluated := eval.EvaluateSSHCipher(cipher, scan.cfg)
+		v.add(evaluated)
+		result.algorithms = append(result.algorithms, algorithmRow(port, "Cipher", name, evaluated, nil))
+		result.records = append(result.records, algorithmRecord(record, report.KindCipher, name, evaluated, nil))
+	}
+
+	for _, name := range offered.MACs {
+		mac, ok := sshprobe.MACByName(name)
+		if !ok {
+			result.algorithms = append(result.algorithms, unknownAlgorithmRow(port, "MAC", name))
+			result.records = append(result.records, unknownAlgorithmRecord(record, report.KindMAC, name))
+			continue
+		}
+		evaluated := eval.EvaluateSSHMAC(mac, scan.cfg)
+		v.add(evaluated)
+		result.algorithms = append(result.algorithms, algorithmRow(port, "MAC", name, evaluated, nil))
+		result.records = append(result.records, algorithmRecord(record, report.KindMAC, name, evaluated, nil))
+	}
+
+	result.verdict = v
+	return result
+}
+
+// hostKeyRow renders one host key and returns its row, its record based on
+// record, and the verdict on it.
+func (scan *sshScan) hostKeyRow(port string, record report.Result, key sshprobe.HostKey) (table.Row, report.Result, verdict) {
+	result := eval.EvaluateSSHHostKey(key, scan.cfg, scan.opts)
+	row := table.Row{port, key.Key.Type(), display.FormatStatus(result.Status), result.Algorithm, "", "", ""}
+	if result.Length > 0 {
+		row[4] = fmt.Sprintf("%d bits", result.Length)
+	}
+	row[5] = fmt.Sprintf("%s %s", display.FormatStatus(checkStatus(result, "ssh-signature")), result.SignatureAlgorithm)
+
+	details := []string{"Fingerprint: " + ssh.FingerprintSHA256(key.Key)}
+	if result.Curve != "" {
+		details = append(details, "Curve: "+result.Curve)
+	}
+	if cert := key.Certificate; cert != nil {
+		details = append(details, fmt.Sprintf("Host certificate signed by %s %s", cert.SignatureKey.Type(), ssh.FingerprintSHA256(cert.SignatureKey)))
+	}
+	if findings := findingDetails(result); findings != "" {
+		details = append(details, findings)
+	}
+	row[6] = strings.Join(details, "; ")
+	hostKey := record.Evaluated(report.KindHostKey, key.Key.Type(), result)
+	hostKey.Details = row[6].(string)
+	var v verdict
+	v.add(result)
+	return row, hostKey, v
+}
+
+func algorithmRow(port, kind, name string, result *eval.EvaluationResult, details []string) table.Row {
+	length := ""
+	if result.Length > 0 {
+		length = fmt.Sprintf("%d bits", result.Length)
+	}
+	if findings := findingDetails(result); findings != "" {
+		details = append(details, findings)
+	}
+	return table.Row{port, kind, name, display.FormatStatus(result.Status), result.Algorithm, length, strings.Join(details, "; ")}
+}
+
+func unknownAlgorithmRow(port, kind, name string) table.Row {
+	return table.Row{port, kind, name, display.FormatStatus("Unknown"), "", "", "Algorithm is not in the catalog"}
+}
+
+// algorithmRecord mirrors algorithmRow.
+func algorithmRecord(record report.Result, kind, name string, result *eval.EvaluationResult, details []string) report.Result {
+	record = record.Evaluated(kind, name, result)
+	record.Details = strings.Join(details, "; ")
+	return record
+}
+
+// unknownAlgorithmRecord mirrors unknownAlgorithmRow.
+func unknownAlgorithmRecord(record report.Result, kind, name string) report.Result {
+	return record.Noted(kind, name, "Unknown", "Algorithm is not in the catalog")
+}
+
+func newHostKeyTable() table.Writer {
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Port", "Host Key", "Status", "Algorithm", "Key Length", "Signature", "Details"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 8},
+		{Number: 2, WidthMax: 22},
+		{Number: 3, WidthMax: 25},
+		{Number: 4, WidthMax: 10},
+		{Number: 5, WidthMax: 12},
+		{Number: 6, WidthMax: 30, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 7, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+	})
+	return t
+}
+
+func newSSHAlgorithmTable() table.Writer {
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Port", "Type", "Algorithm", "Status", "Primitive", "Key Length", "Details"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 8},
+		{Number: 2, AutoMerge: true, WidthMax: 12},
+		{Number: 3, WidthMax: 36, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 4, WidthMax: 25},
+		{Number: 5, WidthMax: 20, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 6, WidthMax: 12},
+		{Number: 7, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+	})
+	return t
+}
+
+func init() {
+	sshCmd.Flags().StringP("standard", "s", "NIST", "Security standard (see 'keylength-check standards list')")
+	sshCmd.Flags().StringP("ports", "p", "22", "Comma-separated ports and ranges (e.g., 22,2222)")
+	sshCmd.Flags().StringP("timeout", "t", "5s", "Connection timeout (e.g., 3s, 10s)")
+	sshCmd.Flags().Int("concurrency", 4, "Number of ports to scan at once")
+	sshCmd.Flags().Float64("rate-limit", 0, "Maximum connections per second to the host (0 for no limit)")
+	sshCmd.Flags().String("proxy", "", "HTTP CONNECT or SOCKS5 proxy URL, or none (default: $HTTPS_PROXY, honoring $NO_PROXY)")
+	sshCmd.Flags().StringSlice("debian-blocklist", nil, "Debian weak-key blocklist files (openssl-blacklist format)")
+	sshCmd.Flags().StringSlice("key-blocklist", nil, "Compromised-key blocklist files of SPKI SHA-256 fingerprints")
+	sshCmd.Flags().String("fail-on", failOnFail, "Lowest level that fails the exit code: warn, fail or error")
+	sshCmd.Flags().StringP("output", "o", report.FormatText, "Output format: text, json, yaml or csv")
+	rootCmd.AddCommand(sshCmd)
+}
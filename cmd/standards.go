@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+)
+
+var standardsCmd = &cobra.Command{
+	Use:   "standards",
+	Short: "List, inspect and compare security standards",
+	Long:  `Standards shows the security profiles available to --standard, including those added by configuration files.`,
+}
+
+var standardsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available standards",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd, "")
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+
+		display.PrintSection("Available Standards", "")
+		t := display.CreateTable()
+		t.AppendHeader(table.Row{"Name", "RSA", "ECC", "Symmetric", "Cut-off", "Source"})
+		for _, name := range cfg.AvailableStandards() {
+			standard, _ := cfg.Standard(name)
+			fields := fieldMap(standard)
+			t.AppendRow(table.Row{name, fields["RSA"], fields["ECC"], fields["Symmetric"], fields["Cut-off Year"], fields["Source"]})
+		}
+		t.SetColumnConfigs([]table.ColumnConfig{
+			{Number: 6, WidthMax: 60, WidthMaxEnforcer: text.WrapSoft},
+		})
+		t.Render()
+	},
+}
+
+var standardsShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show every threshold, transition and allowed list of a standard",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		cfg, err := loadConfig(cmd, name)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+
+		display.PrintSection("Standard", "")
+		display.PrintInfo(
+			display.FormatKeyValue("Name", display.RenderMarkdown(fmt.Sprintf("`%s`", name))),
+			display.FormatKeyValue("Defined By", strings.Join(cfg.Origins(name), " -> ")),
+		)
+		fmt.Println()
+
+		t := display.CreateTable()
+		t.AppendHeader(table.Row{"Setting", "Value"})
+		for _, field := range cfg.GetStandard().Fields() {
+			t.AppendRow(table.Row{field.Name, field.Value})
+		}
+		t.SetColumnConfigs([]table.ColumnConfig{
+			{Number: 2, WidthMax: 70, WidthMaxEnforcer: text.WrapSoft},
+		})
+		t.Render()
+
+		display.PrintSection("Thresholds In Effect", "")
+		t = display.CreateTable()
+		t.AppendHeader(table.Row{"Algorithm", "Minimum"})
+		for _, algorithm := range []string{"RSA", "ECC", "Symmetric"} {
+			t.AppendRow(table.Row{algorithm, fmt.Sprintf("%d bits", cfg.GetThreshold(algorithm))})
+		}
+		t.Render()
+	},
+}
+
+var standardsDiffCmd = &cobra.Command{
+	Use:   "diff [standard-a] [standard-b]",
+	Short: "Compare two standards field by field",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd, "")
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+
+		standards := make([]config.Standard, 0, len(args))
+		for _, name := range args {
+			standard, ok := cfg.Standard(name)
+			if !ok {
+				display.PrintError(fmt.Sprintf("Unknown standard '%s'. Run 'keylength-check standards list' to see available standards.", name))
+				os.Exit(exitError)
+			}
+			standards = append(standards, standard)
+		}
+
+		display.PrintSection(fmt.Sprintf("%s vs %s", args[0], args[1]), "")
+		diffs := config.DiffStandards(standards[0], standards[1])
+		if len(diffs) == 0 {
+			fmt.Printf("[%s] No differences.\n", display.SuccessSymbol)
+			return
+		}
+
+		t := display.CreateTable()
+		t.AppendHeader(table.Row{"Setting", args[0], args[1]})
+		for _, diff := range diffs {
+			t.AppendRow(table.Row{diff.Field, diff.Left, diff.Right})
+		}
+		t.SetColumnConfigs([]table.ColumnConfig{
+			{Number: 2, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+			{Number: 3, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+		})
+		t.Render()
+	},
+}
+
+func fieldMap(standard config.Standard) map[string]string {
+	fields := map[string]string{}
+	for _, field := range standard.Fields() {
+		fields[field.Name] = field.Value
+	}
+	return fields
+}
+
+func init() {
+	standardsCmd.AddCommand(standardsListCmd)
+	standardsCmd.AddCommand(standardsShowCmd)
+	standardsCmd.AddCommand(standardsDiffCmd)
+	rootCmd.AddCommand(standardsCmd)
+}
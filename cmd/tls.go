@@ -0,0 +1,1041 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Horiodino/key-length/cmd/display"
+	"github.com/Horiodino/key-length/internal/clientcert"
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/proxy"
+	"github.com/Horiodino/key-length/internal/report"
+	"github.com/Horiodino/key-length/internal/revocation"
+	"github.com/Horiodino/key-length/internal/starttls"
+	"github.com/Horiodino/key-length/internal/targets"
+	"github.com/Horiodino/key-length/internal/tlsprobe"
+	"github.com/Horiodino/key-length/internal/trust"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+)
+
+var tlsCmd = &cobra.Command{
+	Use:   "tls [host]",
+	Short: "Evaluate the TLS certificate chain of a remote server",
+	Long: `TLS connects to a remote server and evaluates every certificate it presents.
+
+The chain is verified against the system roots, or those in --ca-file, and
+the leaf against the hostname. A port is only counted as secure when its chain
+is trusted and the weakest certificate in it meets the standard.
+
+With --enumerate, every TLS version from 1.0 to 1.3 and every cipher suite the
+server accepts is listed, and each suite's bulk cipher key is evaluated against
+the standard's Symmetric threshold. The key exchange groups the server accepts
+and the size of its DHE prime are evaluated against the ECC and DH thresholds.
+
+With --check-revocation, the leaf's revocation status is read from the OCSP
+response stapled in the handshake, or else fetched from its OCSP responder or
+CRL distribution point. The response must be signed by the issuer or its
+delegated responder, and the signing key is evaluated against the standard.
+A revoked leaf is insecure; an unknown status is a warning.
+
+Many hosts can be scanned at once from --targets-file, or by giving a CIDR
+range as the host. Targets are scanned --concurrency at a time, each one is
+printed as it completes, and the summary counts the targets by status.
+
+The exit code is 0 when every target meets the standard, 1 when any does not,
+2 when --fail-on is warn and there are only warnings, 3 on bad usage or when
+no target could be reached, and 4 when only some targets could be reached.
+A policy violation exits 1 even when some targets could not be reached.
+
+With --output json, yaml or csv, the results are written to stdout in a
+versioned schema instead, and errors to stderr.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		standard, _ := cmd.Flags().GetString("standard")
+		portsStr, _ := cmd.Flags().GetString("ports")
+		checkExpiry, _ := cmd.Flags().GetBool("check-expiry")
+		timeoutStr, _ := cmd.Flags().GetString("timeout")
+		caFile, _ := cmd.Flags().GetString("ca-file")
+		enumerate, _ := cmd.Flags().GetBool("enumerate")
+		startTLS, _ := cmd.Flags().GetString("starttls")
+		sni, _ := cmd.Flags().GetString("sni")
+		connectTo, _ := cmd.Flags().GetString("connect-to")
+		allAddresses, _ := cmd.Flags().GetBool("all-addresses")
+		targetsFile, _ := cmd.Flags().GetString("targets-file")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+		proxyURL, _ := cmd.Flags().GetString("proxy")
+		clientCertFile, _ := cmd.Flags().GetString("client-cert")
+		clientKeyFile, _ := cmd.Flags().GetString("client-key")
+		clientCertPassword, _ := cmd.Flags().GetString("client-cert-password")
+		checkRevocation, _ := cmd.Flags().GetBool("check-revocation")
+		failOn, err := failOnFlag(cmd)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+		rep := report.New("tls", standard)
+
+		if len(args) == 0 && targetsFile == "" {
+			display.PrintError("Specify a host or --targets-file.")
+			os.Exit(exitError)
+		}
+		input := ""
+		if len(args) > 0 {
+			input = trimTarget(args[0])
+		}
+
+		ports, err := targets.ParsePorts(portsStr)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Invalid ports: %v", err))
+			os.Exit(exitError)
+		}
+		if len(ports) == 0 {
+			display.PrintError("No valid ports specified.")
+			os.Exit(exitError)
+		}
+		if startTLS != "" && startTLS != "none" && !starttls.Supported(startTLS) {
+			display.PrintError(fmt.Sprintf("Unsupported STARTTLS protocol '%s' (supported: %s, none).", startTLS, strings.Join(starttls.Protocols, ", ")))
+			os.Exit(exitError)
+		}
+		if concurrency < 1 {
+			display.PrintError("--concurrency must be at least 1.")
+			os.Exit(exitError)
+		}
+
+		var list []targets.Target
+		if targetsFile != "" {
+			list, err = targets.Load(targetsFile, ports)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("Targets file error: %v", err))
+				os.Exit(exitError)
+			}
+		}
+		if input != "" {
+			expanded, err := targets.Expand(input, ports)
+			if err != nil {
+				display.PrintError(err.Error())
+				os.Exit(exitError)
+			}
+			list = append(list, expanded...)
+		}
+		if len(list) == 0 {
+			display.PrintError("No targets to scan.")
+			os.Exit(exitError)
+		}
+		multiple := targetsFile != "" || strings.Contains(input, "/")
+
+		connectTo = strings.Trim(connectTo, "[]")
+		if connectTo != "" && net.ParseIP(connectTo) == nil {
+			display.PrintError(fmt.Sprintf("--connect-to must be an IP address, got '%s'.", connectTo))
+			os.Exit(exitError)
+		}
+		if connectTo != "" && allAddresses {
+			display.PrintError("--connect-to and --all-addresses cannot be used together.")
+			os.Exit(exitError)
+		}
+		if connectTo != "" && multiple {
+			display.PrintError("--connect-to cannot be used with --targets-file or a CIDR range.")
+			os.Exit(exitError)
+		}
+
+		timeout := 5 * time.Second
+		if timeoutStr != "" {
+			dur, err := time.ParseDuration(timeoutStr)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("Invalid timeout format '%s': %v. Using default 5s.", timeoutStr, err))
+			} else {
+				timeout = dur
+			}
+		}
+
+		envProxy, noProxy := proxy.FromEnvironment()
+		switch proxyURL {
+		case "":
+			proxyURL = envProxy
+		case "none":
+			proxyURL = ""
+		}
+		dialer, err := proxy.New(proxyURL, noProxy, timeout)
+		if err != nil {
+			display.PrintError(err.Error())
+			os.Exit(exitError)
+		}
+
+		scan := &tlsScan{
+			sni:          sni,
+			startTLS:     startTLS,
+			allAddresses: allAddresses,
+			timeout:      timeout,
+			limiter:      targets.NewLimiter(rateLimit),
+			proxy:        dialer,
+			checkExpiry:  checkExpiry,
+			enumerate:    enumerate,
+		}
+		if checkRevocation {
+			// OCSP and CRL fetches go through the same proxy and rate limit.
+			scan.revocation = &revocation.Checker{Client: &http.Client{
+				Timeout: timeout,
+				Transport: &http.Transport{DialContext: func(_ context.Context, network, address string) (net.Conn, error) {
+					return scan.dial(network, address)
+				}},
+			}}
+		}
+
+		host := list[0].Host
+		if !multiple {
+			ports = ports[:0]
+			for _, target := range list {
+				ports = append(ports, target.Port)
+			}
+			scan.pinned = []string{host}
+			switch {
+			case connectTo != "":
+				scan.pinned = []string{connectTo}
+			case allAddresses:
+				resolved, err := resolveAddresses(host, timeout)
+				if err != nil {
+					display.PrintError(fmt.Sprintf("Failed to resolve %s: %v", host, err))
+					os.Exit(exitError)
+				}
+				scan.pinned = resolved
+			}
+		}
+
+		display.PrintSection("TLS Analysis", "")
+		if multiple {
+			display.PrintInfo(display.FormatKeyValue("Targets", display.RenderMarkdown(fmt.Sprintf("`%d`", len(list)))))
+			if targetsFile != "" {
+				display.PrintInfo(display.FormatKeyValue("Targets File", display.RenderMarkdown(fmt.Sprintf("`%s`", targetsFile))))
+			}
+			display.PrintInfo(display.FormatKeyValue("Concurrency", display.RenderMarkdown(fmt.Sprintf("`%d`", concurrency))))
+		} else {
+			display.PrintInfo(
+				display.FormatKeyValue("Host", display.RenderMarkdown(fmt.Sprintf("`%s`", host))),
+				display.FormatKeyValue("Ports", display.RenderMarkdown(fmt.Sprintf("`%s`", strings.Join(ports, ", ")))),
+			)
+		}
+		if dialer != nil {
+			display.PrintInfo(display.FormatKeyValue("Proxy", display.RenderMarkdown(fmt.Sprintf("`%s`", dialer.URL.Redacted()))))
+		}
+		if rateLimit > 0 {
+			display.PrintInfo(display.FormatKeyValue("Rate Limit", display.RenderMarkdown(fmt.Sprintf("`%g connections/s per host`", rateLimit))))
+		}
+		display.PrintInfo(
+			display.FormatKeyValue("Timeout", display.RenderMarkdown(fmt.Sprintf("`%s`", timeout))),
+			display.FormatKeyValue("Standard", display.RenderMarkdown(fmt.Sprintf("`%s`", standard))),
+		)
+		if sni != "" && sni != host {
+			display.PrintInfo(display.FormatKeyValue("SNI", display.RenderMarkdown(fmt.Sprintf("`%s`", sni))))
+		}
+		if !multiple && scan.pinned[0] != host {
+			display.PrintInfo(display.FormatKeyValue("Addresses", display.RenderMarkdown(fmt.Sprintf("`%s`", strings.Join(scan.pinned, ", ")))))
+		}
+		display.Println()
+
+		cfg, err := loadConfig(cmd, standard)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Config error: %v", err))
+			os.Exit(exitError)
+		}
+		if source := cfg.GetStandard().Source; source != "" {
+			display.PrintInfo(display.FormatKeyValue("Source", source))
+			display.Println()
+		}
+		scan.cfg = cfg
+
+		scan.opts, err = loadEvalOptions(cmd)
+		if err != nil {
+			display.PrintError(fmt.Sprintf("Blocklist error: %v", err))
+			os.Exit(exitError)
+		}
+
+		if caFile != "" {
+			scan.roots, err = trust.LoadCAFile(caFile)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("CA file error: %v", err))
+				os.Exit(exitError)
+			}
+		}
+
+		if clientKeyFile != "" && clientCertFile == "" {
+			display.PrintError("--client-key requires --client-cert.")
+			os.Exit(exitError)
+		}
+		if clientCertFile != "" {
+			clientCert, err := clientcert.Load(clientCertFile, clientKeyFile, clientCertPassword)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("Client certificate error: %v", err))
+				os.Exit(exitError)
+			}
+			chain, err := clientcert.Chain(clientCert)
+			if err != nil {
+				display.PrintError(fmt.Sprintf("Client certificate error: %v", err))
+				os.Exit(exitError)
+			}
+			scan.clientCert = &clientCert
+
+			display.PrintSection("Client Certificate", "")
+			evaluated := eval.EvaluateChain(chain, cfg, checkExpiry, scan.opts)
+			t := newCertTable()
+			t.AppendRows(chainRows("Client", evaluated, checkExpiry))
+			t.Render()
+			rep.Results = append(rep.Results, chainRecords(report.Result{Source: clientCertFile}, evaluated)...)
+			display.Println()
+		}
+
+		if multiple {
+			finish(rep, format, failOn, scan.stream(list, concurrency, rep))
+		}
+
+		totalTargets := len(ports) * len(scan.pinned)
+		spinnerActive := false
+		var s spinner.Model
+		if totalTargets > 1 {
+			s = display.NewSpinner(fmt.Sprintf("Checking %d targets", totalTargets))
+			spinnerActive = true
+		} else {
+			display.Printf("[%s] Checking %s...\n", display.InfoSymbol, net.JoinHostPort(scan.pinned[0], ports[0]))
+		}
+
+		results := make([]*tlsResult, len(list))
+		targets.Run(list, concurrency, scan.scanTarget, func(i int, result *tlsResult) {
+			results[i] = result
+		})
+
+		if spinnerActive {
+			display.StopSpinner(s, true)
+		}
+
+		t, suites, groups := newCertTable(), newSuiteTable(), newGroupTable()
+		secureCount := 0
+		totalResults := 0
+		var o outcome
+		for _, result := range results {
+			t.AppendRows(result.certs)
+			suites.AppendRows(result.suites)
+			groups.AppendRows(result.groups)
+			secureCount += result.secure
+			totalResults += result.connected
+			result.tally(&o)
+			rep.Results = append(rep.Results, result.records...)
+		}
+
+		if totalResults > 0 || totalTargets > totalResults {
+			t.Render()
+			if enumerate {
+				display.PrintSection("Protocols and Cipher Suites", "")
+				suites.Render()
+				display.PrintSection("Key Exchange Groups", "")
+				groups.Render()
+			}
+			display.PrintScanSummary(host, totalTargets, secureCount)
+		} else if totalTargets > 1 && totalResults == 0 {
+			display.PrintError("No TLS connections could be successfully evaluated.")
+		}
+		finish(rep, format, failOn, o)
+	},
+}
+
+// trimTarget strips a URL scheme and path from a host argument, leaving
+// CIDR ranges whole.
+func trimTarget(input string) string {
+	input = strings.TrimPrefix(input, "https://")
+	input = strings.TrimPrefix(input, "http://")
+	if _, err := netip.ParsePrefix(input); err == nil {
+		return input
+	}
+	return strings.Split(input, "/")[0]
+}
+
+func newCertTable() table.Writer {
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Port", "Position", "Status", "Algorithm", "Key Length", "Signature", "Details"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 24},
+		{Number: 2, WidthMax: 16},
+		{Number: 3, WidthMax: 25},
+		{Number: 4, WidthMax: 10},
+		{Number: 5, WidthMax: 12},
+		{Number: 6, WidthMax: 25, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 7, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+	})
+	return t
+}
+
+func newSuiteTable() table.Writer {
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Port", "Protocol", "Cipher Suite", "Status", "Cipher", "Key Length", "Details"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 24},
+		{Number: 2, AutoMerge: true, WidthMax: 8},
+		{Number: 3, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+		{Number: 4, WidthMax: 25},
+		{Number: 5, WidthMax: 20},
+		{Number: 6, WidthMax: 12},
+		{Number: 7, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+	})
+	return t
+}
+
+func newGroupTable() table.Writer {
+	t := display.CreateTable()
+	t.AppendHeader(table.Row{"Port", "Group", "Type", "Protocol", "Status", "Key Length", "Details"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true, WidthMax: 24},
+		{Number: 2, WidthMax: 16},
+		{Number: 3, WidthMax: 8},
+		{Number: 4, WidthMax: 8},
+		{Number: 5, WidthMax: 25},
+		{Number: 6, WidthMax: 12},
+		{Number: 7, WidthMax: 45, WidthMaxEnforcer: text.WrapSoft},
+	})
+	return t
+}
+
+// tlsScan holds what every target of a tls run is evaluated with.
+type tlsScan struct {
+	cfg          *config.Config
+	opts         eval.Options
+	roots        *x509.CertPool
+	sni          string
+	startTLS     string
+	allAddresses bool
+	// pinned, when set, replaces the addresses of every target.
+	pinned      []string
+	timeout     time.Duration
+	limiter     *targets.Limiter
+	proxy       *proxy.Dialer
+	clientCert  *tls.Certificate
+	checkExpiry bool
+	enumerate   bool
+	// revocation is set when the leaf's revocation status is checked.
+	revocation *revocation.Checker
+}
+
+// tlsResult holds the rows of one host and port, and the counts that go
+// into the summary. A target resolved to several addresses counts each.
+type tlsResult struct {
+	target    targets.Target
+	certs     []table.Row
+	suites    []table.Row
+	groups    []table.Row
+	records   []report.Result
+	scanned   int
+	connected int
+	secure    int
+	insecure  int
+	// warned counts the connected addresses, and sets of backends that
+	// differ, with a warning.
+	warned int
+	// incomplete counts the connected addresses where a probe failed.
+	incomplete int
+	statuses   map[string]int
+}
+
+// tally adds the result's addresses to o: those that could not be reached
+// as errors, and the rest as evaluated.
+func (result *tlsResult) tally(o *outcome) {
+	o.evaluated += result.connected
+	o.insecure += result.insecure
+	o.warned += result.warned
+	o.incomplete += result.incomplete
+	o.errors += result.scanned - result.connected
+}
+
+// stream scans many targets, printing each as it completes, and then the
+// summary of all of them. It adds the records of every target to rep, in
+// the order of list, and returns the outcome of the run.
+func (scan *tlsScan) stream(list []targets.Target, concurrency int, rep *report.Report) outcome {
+	scanned, connected, secureCount := 0, 0, 0
+	statuses := map[string]int{}
+	var o outcome
+	records := make([][]report.Result, len(list))
+	targets.Run(list, concurrency, scan.scanTarget, func(i int, result *tlsResult) {
+		label := result.target.Address()
+		if protocol := scan.protocolFor(result.target.Port); protocol != "" {
+			label += "/" + protocol
+		}
+		display.PrintSection(label, "")
+		t := newCertTable()
+		t.AppendRows(result.certs)
+		t.Render()
+		if len(result.suites) > 0 {
+			t := newSuiteTable()
+			t.AppendRows(result.suites)
+			t.Render()
+		}
+		if len(result.groups) > 0 {
+			t := newGroupTable()
+			t.AppendRows(result.groups)
+			t.Render()
+		}
+
+		scanned += result.scanned
+		connected += result.connected
+		secureCount += result.secure
+		for status, n := range result.statuses {
+			statuses[status] += n
+		}
+		result.tally(&o)
+		records[i] = result.records
+	})
+	display.PrintTargetsSummary(scanned, connected, secureCount, statuses)
+	for _, target := range records {
+		rep.Results = append(rep.Results, target...)
+	}
+	return o
+}
+
+// protocolFor returns the STARTTLS protocol to use on port, or "".
+func (scan *tlsScan) protocolFor(port string) string {
+	switch scan.startTLS {
+	case "":
+		return starttls.ForPort(port)
+	case "none":
+		return ""
+	}
+	return scan.startTLS
+}
+
+// dial opens a connection, through the proxy if one is set, once the rate
+// limit for the address's host allows.
+func (scan *tlsScan) dial(network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	scan.limiter.Wait(host)
+	if scan.proxy != nil {
+		return scan.proxy.Dial(network, address)
+	}
+	return net.DialTimeout(network, address, scan.timeout)
+}
+
+// scanTarget evaluates every address of one host and port, and compares
+// them when there is more than one.
+func (scan *tlsScan) scanTarget(target targets.Target) *tlsResult {
+	result := &tlsResult{target: target, statuses: map[string]int{}}
+	protocol := scan.protocolFor(target.Port)
+	suffix := ""
+	if protocol != "" {
+		suffix = "/" + protocol
+	}
+	serverName := target.Host
+	if scan.sni != "" {
+		serverName = scan.sni
+	}
+	record := report.Result{Source: target.Host, Port: target.Port, Protocol: protocol}
+
+	addresses := scan.pinned
+	if addresses == nil {
+		addresses = []string{target.Host}
+		if scan.allAddresses {
+			resolved, err := resolveAddresses(target.Host, scan.timeout)
+			if err != nil {
+				result.certs = append(result.certs, table.Row{target.Port + suffix, "-", display.FormatStatus("Resolution Failed"), "", "", "", fmt.Sprintf("Error: %v", err)})
+				result.records = append(result.records, record.Failed(report.KindConnection, "Resolution Failed", err))
+				result.scanned++
+				result.statuses["Resolution Failed"]++
+				return result
+			}
+			addresses = resolved
+		}
+	}
+
+	var backends []eval.Backend
+	for _, address := range addresses {
+		label := target.Port
+		at := record
+		if address != target.Host {
+			label = net.JoinHostPort(address, target.Port)
+			at.Address = address
+		}
+		label += suffix
+
+		result.scanned++
+		backend, status := scan.run(result, at, label, net.JoinHostPort(address, target.Port), serverName, protocol)
+		result.statuses[status]++
+		if backend == nil {
+			continue
+		}
+		backends = append(backends, *backend)
+		result.connected++
+		switch status {
+		case "Secure":
+			result.secure++
+		case "Incomplete":
+		default:
+			result.insecure++
+		}
+	}
+
+	if differences := eval.CompareBackends(backends); len(differences) > 0 {
+		result.certs = append(result.certs, table.Row{target.Port + suffix, "Backends", display.FormatStatus("Warning: Backends Differ"), "", "", "", strings.Join(differences, "; ")})
+		result.records = append(result.records, record.Noted(report.KindBackends, "", "Backends Differ", strings.Join(differences, "; ")))
+		result.warned++
+	}
+	return result
+}
+
+// run evaluates one address and port, adding rows under label and records
+// based on record. It returns nil when no certificate could be fetched, and
+// the status the target is counted under: Secure, Insecure, Incomplete when
+// a probe failed, or why it could not be trusted.
+func (scan *tlsScan) run(result *tlsResult, record report.Result, label, address, serverName, protocol string) (*eval.Backend, string) {
+	conn, request, err := scan.dialTLS(address, serverName, protocol)
+	if err != nil {
+		result.certs = append(result.certs, table.Row{label, "-", display.FormatStatus("Connection Failed"), "", "", "", fmt.Sprintf("Error: %v", err)})
+		result.records = append(result.records, record.Failed(report.KindConnection, "Connection Failed", err))
+		if request != nil {
+			row, clientAuth := scan.clientAuthRow(label, record, request, err)
+			result.certs = append(result.certs, row)
+			result.records = append(result.records, clientAuth)
+		}
+		return nil, "Connection Failed"
+	}
+
+	state := conn.ConnectionState()
+	var rejected error
+	if request != nil && state.Version == tls.VersionTLS13 {
+		rejected = clientCertRejected(conn)
+	}
+	conn.Close()
+	if request != nil || scan.clientCert != nil {
+		row, clientAuth := scan.clientAuthRow(label, record, request, rejected)
+		result.certs = append(result.certs, row)
+		result.records = append(result.records, clientAuth)
+	}
+
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		result.certs = append(result.certs, table.Row{label, "-", display.FormatStatus("No Certificate"), "", "", "", "Server did not present a certificate."})
+		result.records = append(result.records, record.Failed(report.KindConnection, "No Certificate", errors.New("server did not present a certificate")))
+		return nil, "No Certificate"
+	}
+
+	verified := trust.Verify(certs, serverName, scan.roots, time.Now())
+	result.certs = append(result.certs, table.Row{label, "Trust", display.FormatStatus(verified.Status), "", "", "", verified.Message})
+	result.records = append(result.records, record.Noted(report.KindTrust, "", verified.Status, verified.Message))
+
+	chain := eval.EvaluateChain(certs, scan.cfg, scan.checkExpiry, scan.opts)
+	result.certs = append(result.certs, chainRows(label, chain, scan.checkExpiry)...)
+	result.records = append(result.records, chainRecords(record, chain)...)
+	backend := &eval.Backend{Address: label, Chain: chain, Trust: verified.Status, Leaf: certs[0]}
+
+	v := verdict{insecure: !strings.HasPrefix(chain.Status, "Secure")}
+	for _, link := range chain.Links {
+		if link.Err == nil {
+			v.warned = v.warned || hasWarning(link.Result)
+		}
+	}
+	if scan.revocation != nil {
+		row, checked, revoked := scan.revocationRow(label, record, certs, verified, state.OCSPResponse)
+		result.certs = append(result.certs, row)
+		result.records = append(result.records, checked)
+		v.merge(revoked)
+	}
+	if scan.enumerate {
+		prober := &tlsprobe.Prober{Address: address, ServerName: serverName, Timeout: scan.timeout, Dial: scan.dial}
+		if protocol != "" {
+			prober.Upgrade = func(conn net.Conn) error { return starttls.Upgrade(conn, protocol, serverName) }
+		}
+		protocols, err := prober.Enumerate()
+		if err != nil {
+			result.suites = append(result.suites, table.Row{label, "-", "", display.FormatStatus("Enumeration Failed"), "", "", fmt.Sprintf("Error: %v", err)})
+			result.records = append(result.records, record.Failed(report.KindProtocol, "Enumeration Failed", err))
+			v.incomplete = true
+		} else {
+			rows, records, suites := protocolRows(label, record, protocols, scan.cfg)
+			result.suites = append(result.suites, rows...)
+			result.records = append(result.records, records...)
+			v.merge(suites)
+		}
+
+		kex, err := prober.KeyExchange()
+		if err != nil {
+			result.groups = append(result.groups, table.Row{label, "-", "", "", display.FormatStatus("Probe Failed"), "", fmt.Sprintf("Error: %v", err)})
+			result.records = append(result.records, record.Failed(report.KindGroup, "Probe Failed", err))
+			v.incomplete = true
+		} else {
+			rows, records, groups := groupRows(label, record, kex, scan.cfg)
+			result.groups = append(result.groups, rows...)
+			result.records = append(result.records, records...)
+			v.merge(groups)
+		}
+	}
+
+	if v.warned {
+		result.warned++
+	}
+	if v.incomplete {
+		result.incomplete++
+	}
+	switch {
+	case !verified.Trusted():
+		return backend, verified.Status
+	case v.insecure:
+		return backend, "Insecure"
+	case v.incomplete:
+		return backend, "Incomplete"
+	}
+	return backend, "Secure"
+}
+
+// revocationRow checks the leaf's revocation status against the issuer from
+// the verified path, or the presented chain when it did not verify, and
+// returns its row and record and the verdict on the answer.
+func (scan *tlsScan) revocationRow(label string, record report.Result, certs []*x509.Certificate, verified trust.Result, stapled []byte) (table.Row, report.Result, verdict) {
+	var issuer *x509.Certificate
+	switch {
+	case len(verified.Chain) > 1:
+		issuer = verified.Chain[1]
+	case len(certs) > 1:
+		issuer = certs[1]
+	}
+	checked := scan.revocation.Check(certs[0], issuer, stapled, time.Now())
+	result := eval.EvaluateRevocation(checked, scan.cfg, scan.opts)
+
+	status := checked.Status
+	if checked.Source != "" {
+		status += " (" + checked.Source + ")"
+	}
+	row := table.Row{label, "Revocation", display.FormatStatus(status), result.Algorithm, "", "", ""}
+	if result.Length > 0 {
+		row[4] = fmt.Sprintf("%d bits", result.Length)
+	}
+	if result.SignatureAlgorithm != "" {
+		row[5] = fmt.Sprintf("%s %s", display.FormatStatus(checkStatus(result, "revocation-signature")), result.SignatureAlgorithm)
+	}
+
+	var details []string
+	if checked.Message != "" {
+		details = append(details, checked.Message)
+	}
+	if checked.Signer != nil {
+		details = append(details, "Signed by: "+checked.Signer.Subject.String())
+	}
+	record = record.Evaluated(report.KindRevocation, status, result)
+	record.Details = strings.Join(details, "; ")
+	if findings := findingDetails(result); findings != "" {
+		details = append(details, findings)
+	}
+	row[6] = strings.Join(details, "; ")
+	var v verdict
+	v.add(result)
+	return row, record, v
+}
+
+// resolveAddresses returns every A and AAAA record of host, or host itself
+// when it is already an IP address.
+func resolveAddresses(host string, timeout time.Duration) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	seen := map[string]bool{}
+	for _, ip := range ips {
+		address := ip.IP.String()
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return addresses, nil
+}
+
+// dialTLS connects to hostPort, runs the STARTTLS exchange for protocol when
+// one is set, and completes a handshake without verifying the chain, which
+// trust.Verify does separately. The client certificate, if any, is sent when
+// the server asks for one, and the server's CertificateRequest is returned
+// even when the handshake fails.
+func (scan *tlsScan) dialTLS(hostPort, serverName, protocol string) (*tls.Conn, *tls.CertificateRequestInfo, error) {
+	conn, err := scan.dial("tcp", hostPort)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(scan.timeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if protocol != "" {
+		if err := starttls.Upgrade(conn, protocol, serverName); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	var request *tls.CertificateRequestInfo
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			request = info
+			if scan.clientCert != nil {
+				return scan.clientCert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, request, err
+	}
+	return tlsConn, request, nil
+}
+
+// clientCertRejected waits briefly for an alert after a TLS 1.3 handshake,
+// where the server checks the client certificate only after the client has
+// finished. A server that is waiting for a request has accepted it.
+func clientCertRejected(conn *tls.Conn) error {
+	if err := conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond)); err != nil {
+		return nil
+	}
+	_, err := conn.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	}
+	if err == io.EOF || err == nil {
+		return nil
+	}
+	return err
+}
+
+// clientAuthRow reports whether the server asked for a client certificate,
+// which CAs and signature algorithms it accepts, and whether the client
+// certificate was accepted. rejected is the error the handshake failed with.
+func (scan *tlsScan) clientAuthRow(label string, record report.Result, request *tls.CertificateRequestInfo, rejected error) (table.Row, report.Result) {
+	if request == nil {
+		details := "Server did not ask for a client certificate."
+		return table.Row{label, "Client Auth", display.FormatStatus("Not Requested"), "", "", "", details},
+			record.Noted(report.KindClientAuth, "", "Not Requested", details)
+	}
+
+	var details []string
+	cas := clientcert.AcceptableCAs(request)
+	if len(cas) == 0 {
+		details = append(details, "Acceptable CAs: any")
+	}
+	for _, ca := range cas {
+		details = append(details, "Acceptable CA: "+ca)
+	}
+	if schemes := clientcert.SignatureSchemes(request); len(schemes) > 0 {
+		details = append(details, "Signature algorithms: "+strings.Join(schemes, ", "))
+	}
+
+	status := "Requested"
+	switch {
+	case rejected != nil && scan.clientCert != nil:
+		status = "Client Cert Rejected"
+	case rejected != nil:
+		status = "Client Cert Required"
+	case scan.clientCert != nil:
+		status = "Client Cert Accepted"
+	}
+	if rejected != nil {
+		details = append(details, fmt.Sprintf("Error: %v", rejected))
+	}
+	row := table.Row{label, "Client Auth", display.FormatStatus(status), "", "", "", strings.Join(details, "; ")}
+	record = record.Noted(report.KindClientAuth, "", status, strings.Join(details, "; "))
+	if scan.clientCert != nil {
+		if err := request.SupportsCertificate(scan.clientCert); err != nil {
+			row[6] = strings.Join(append(details, display.FormatStatus("Warning")+" "+err.Error()), "; ")
+			record.Details = strings.Join(append(details, "Warning: "+err.Error()), "; ")
+		}
+	}
+	return row, record
+}
+
+// chainRows renders one row per certificate, leaf first. Chains of more than
+// one certificate start with a row holding the verdict of the weakest link.
+func chainRows(port string, chain *eval.ChainResult, checkExpiry bool) []table.Row {
+	var rows []table.Row
+	if len(chain.Links) > 1 {
+		weakest := chain.Links[chain.Weakest]
+		rows = append(rows, table.Row{
+			port,
+			fmt.Sprintf("Chain (%d)", len(chain.Links)),
+			display.FormatStatus(chain.Status),
+			"", "", "",
+			fmt.Sprintf("Weakest link: %s (%s)", weakest.Position, weakest.Subject),
+		})
+	}
+
+	for _, link := range chain.Links {
+		row := table.Row{port, link.Position, "", "", "", "", ""}
+		details := []string{"Subject: " + link.Subject}
+		if link.Err != nil {
+			row[2] = display.FormatStatus("Parsing Failed")
+			details = append(details, fmt.Sprintf("Cert parse error: %v", link.Err))
+			row[6] = strings.Join(details, "; ")
+			rows = append(rows, row)
+			continue
+		}
+
+		result := link.Result
+		row[2] = display.FormatStatus(result.Status)
+		row[3] = result.Algorithm
+		row[4] = fmt.Sprintf("%d bits", result.Length)
+		if result.SignatureAlgorithm != "" {
+			row[5] = fmt.Sprintf("%s %s", display.FormatStatus(checkStatus(result, "signature")), result.SignatureAlgorithm)
+		}
+
+		if result.Curve != "" {
+			details = append(details, "Curve: "+result.Curve)
+		}
+		for _, finding := range result.Findings {
+			details = append(details, display.FormatStatus(finding.Severity)+" "+finding.Message)
+		}
+		if checkExpiry {
+			expiryDetail := fmt.Sprintf("Expires: %s", result.Expiry)
+			if result.ExpiryWarning != "" {
+				expiryDetail += fmt.Sprintf(" (%s)", display.FormatStatus(result.ExpiryWarning))
+			}
+			details = append(details, expiryDetail)
+		}
+		row[6] = strings.Join(details, "; ")
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// protocolRows renders one row per protocol version followed by the suites
+// accepted with it, their records, and the verdict on all of them.
+func protocolRows(port string, record report.Result, protocols []tlsprobe.Protocol, cfg *config.Config) ([]table.Row, []report.Result, verdict) {
+	var rows []table.Row
+	var records []report.Result
+	var v verdict
+	for _, protocol := range protocols {
+		result := eval.EvaluateProtocol(protocol, cfg)
+		if result == nil {
+			rows = append(rows, table.Row{port, protocol.Name, "", display.FormatStatus("Not Offered"), "", "", ""})
+			records = append(records, record.Noted(report.KindProtocol, protocol.Name, "Not Offered", ""))
+			continue
+		}
+		v.add(result)
+		rows = append(rows, table.Row{port, protocol.Name, "", display.FormatStatus(result.Status), "", "", findingDetails(result)})
+		records = append(records, record.Evaluated(report.KindProtocol, protocol.Name, result))
+
+		for _, suite := range protocol.Suites {
+			result := eval.EvaluateSuite(suite, cfg)
+			v.add(result)
+			suiteRecord := record
+			suiteRecord.Details = protocol.Name
+			records = append(records, suiteRecord.Evaluated(report.KindSuite, suite.Name, result))
+			rows = append(rows, table.Row{
+				port, protocol.Name, suite.Name,
+				display.FormatStatus(result.Status),
+				result.Algorithm,
+				fmt.Sprintf("%d bits", result.Length),
+				findingDetails(result),
+			})
+		}
+	}
+	return rows, records, v
+}
+
+// groupRows renders one row per catalogued group, then the prime the server
+// uses for DHE when the client names no group, with a record for each
+// accepted group, and the verdict on all of them.
+func groupRows(port string, record report.Result, kex *tlsprobe.KeyExchange, cfg *config.Config) ([]table.Row, []report.Result, verdict) {
+	accepted := map[uint16]tlsprobe.AcceptedGroup{}
+	for _, group := range kex.Groups {
+		accepted[group.ID] = group
+	}
+
+	var rows []table.Row
+	var records []report.Result
+	var v verdict
+	for _, group := range tlsprobe.Groups {
+		a, ok := accepted[group.ID]
+		if !ok {
+			rows = append(rows, table.Row{port, group.Name, group.Kind, "", display.FormatStatus("Not Offered"), "", ""})
+			continue
+		}
+		result := eval.EvaluateGroup(a.Group, cfg)
+		v.add(result)
+		records = append(records, record.Evaluated(report.KindGroup, group.Name, result))
+		rows = append(rows, table.Row{port, group.Name, group.Kind, tlsprobe.VersionName(a.Version),
+			display.FormatStatus(result.Status), fmt.Sprintf("%d bits", result.Length), findingDetails(result)})
+	}
+
+	if kex.DHE != nil {
+		result := eval.EvaluateGroup(kex.DHE.Group, cfg)
+		v.add(result)
+		details := "Custom prime"
+		if kex.DHE.ID != 0 {
+			details = "Prime is " + kex.DHE.Name
+		}
+		if findings := findingDetails(result); findings != "" {
+			details += "; " + findings
+		}
+		rows = append(rows, table.Row{port, "Server DHE", kex.DHE.Kind, tlsprobe.VersionName(kex.DHE.Version),
+			display.FormatStatus(result.Status), fmt.Sprintf("%d bits", result.Length), details})
+		dhe := record.Evaluated(report.KindGroup, "Server DHE", result)
+		dhe.Details = details
+		records = append(records, dhe)
+	}
+	return rows, records, v
+}
+
+func findingDetails(result *eval.EvaluationResult) string {
+	var details []string
+	for _, finding := range result.Findings {
+		details = append(details, display.FormatStatus(finding.Severity)+" "+finding.Message)
+	}
+	return strings.Join(details, "; ")
+}
+
+func init() {
+	tlsCmd.Flags().StringP("standard", "s", "NIST", "Security standard (see 'keylength-check standards list')")
+	tlsCmd.Flags().StringP("ports", "p", "443", "Comma-separated ports and ranges (e.g., 443,8443,9000-9010)")
+	tlsCmd.Flags().String("targets-file", "", "File of targets to scan: host[:port] or CIDR per line, or CSV rows of host,port")
+	tlsCmd.Flags().Int("concurrency", 10, "Number of targets to scan at once")
+	tlsCmd.Flags().Float64("rate-limit", 0, "Maximum connections per second to each host (0 for no limit)")
+	tlsCmd.Flags().BoolP("check-expiry", "e", false, "Check certificate expiry date")
+	tlsCmd.Flags().StringP("timeout", "t", "5s", "Connection timeout (e.g., 3s, 10s)")
+	tlsCmd.Flags().String("sni", "", "Server name to send in SNI and verify the certificate against (default: host)")
+	tlsCmd.Flags().String("connect-to", "", "IP address to connect to instead of resolving the host")
+	tlsCmd.Flags().Bool("all-addresses", false, "Evaluate every A and AAAA record of the host separately")
+	tlsCmd.Flags().String("proxy", "", "HTTP CONNECT or SOCKS5 proxy URL, or none (default: $HTTPS_PROXY, honoring $NO_PROXY)")
+	tlsCmd.Flags().String("starttls", "", "Upgrade with STARTTLS first: smtp, imap, pop3, ftp, ldap, xmpp, postgres or none (default: by port)")
+	tlsCmd.Flags().Bool("enumerate", false, "Probe every TLS version, cipher suite and key exchange group the server accepts")
+	tlsCmd.Flags().Bool("check-revocation", false, "Check the leaf's revocation status by stapled OCSP, its OCSP responder or its CRL")
+	tlsCmd.Flags().String("client-cert", "", "Client certificate for mutual TLS: PEM (optionally with its key and chain) or PKCS#12")
+	tlsCmd.Flags().String("client-key", "", "PEM private key of --client-cert (default: read from --client-cert)")
+	tlsCmd.Flags().String("client-cert-password", "", "Password of a PKCS#12 --client-cert")
+	tlsCmd.Flags().String("ca-file", "", "PEM bundle of trusted roots to verify against instead of the system pool")
+	tlsCmd.Flags().StringSlice("debian-blocklist", nil, "Debian weak-key blocklist files (openssl-blacklist format)")
+	tlsCmd.Flags().StringSlice("key-blocklist", nil, "Compromised-key blocklist files of SPKI SHA-256 fingerprints")
+	tlsCmd.Flags().String("fail-on", failOnFail, "Lowest level that fails the exit code: warn, fail or error")
+	tlsCmd.Flags().StringP("output", "o", report.FormatText, "Output format: text, json, yaml or csv")
+	rootCmd.AddCommand(tlsCmd)
+}
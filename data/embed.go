@@ -0,0 +1,15 @@
+package data
+
+import (
+	_ "embed"
+)
+
+// Standards is the default standards file compiled into the binary.
+//
+//go:embed standards.json
+var Standards []byte
+
+// Schema is the JSON Schema every standards file is validated against.
+//
+//go:embed standards.schema.json
+var Schema []byte
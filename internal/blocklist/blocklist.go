@@ -0,0 +1,105 @@
+package blocklist
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+// Blocklist holds SPKI SHA-256 fingerprints of keys known to be compromised,
+// such as keys leaked in firmware or test keys published in repositories.
+type Blocklist struct {
+	entries map[string]entry
+}
+
+type entry struct {
+	source      string
+	description string
+}
+
+// Load reads blocklist files with one SPKI SHA-256 per line, in hex (colons
+// allowed) or base64 as used by pin-sha256. Anything after the fingerprint is
+// kept as a description of the key, and '#' starts a comment.
+func Load(paths ...string) (*Blocklist, error) {
+	b := &Blocklist{entries: map[string]entry{}}
+	for _, path := range paths {
+		if err := b.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *Blocklist) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New("failed to open key blocklist: " + err.Error())
+	}
+	defer f.Close()
+
+	source := filepath.Base(path)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		fingerprint, ok := normalize(fields[0])
+		if !ok {
+			return errors.New("invalid key blocklist entry in " + path + ": " + fields[0])
+		}
+		description := strings.Join(fields[1:], " ")
+		description = strings.TrimSpace(strings.TrimPrefix(description, "#"))
+		b.entries[fingerprint] = entry{source: source, description: description}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.New("failed to read key blocklist: " + err.Error())
+	}
+	return nil
+}
+
+// normalize converts a hex or base64 SHA-256 into lowercase hex.
+func normalize(value string) (string, bool) {
+	value = strings.TrimPrefix(value, "sha256:")
+	if digest, err := hex.DecodeString(strings.ReplaceAll(value, ":", "")); err == nil && len(digest) == 32 {
+		return hex.EncodeToString(digest), true
+	}
+	if digest, err := base64.StdEncoding.DecodeString(value); err == nil && len(digest) == 32 {
+		return hex.EncodeToString(digest), true
+	}
+	return "", false
+}
+
+// Len returns the number of fingerprints loaded.
+func (b *Blocklist) Len() int {
+	return len(b.entries)
+}
+
+// Check reports a critical finding when the hex SPKI SHA-256 fingerprint is
+// blocklisted, naming the file it was listed in.
+func (b *Blocklist) Check(fingerprint string) *types.Finding {
+	if b == nil {
+		return nil
+	}
+
+	e, ok := b.entries[strings.ToLower(fingerprint)]
+	if !ok {
+		return nil
+	}
+	message := "Key is in the compromised-key blocklist " + e.source
+	if e.description != "" {
+		message += " (" + e.description + ")"
+	}
+	return &types.Finding{
+		Check:    "key-blocklist",
+		Severity: types.SeverityCritical,
+		Message:  message,
+	}
+}
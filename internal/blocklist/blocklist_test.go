@@ -0,0 +1,80 @@
+package blocklist
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	first := sha256.Sum256([]byte("leaked firmware key"))
+	second := sha256.Sum256([]byte("test key from a public repository"))
+	third := sha256.Sum256([]byte("vendor disclosure"))
+
+	colons := strings.ToUpper(hex.EncodeToString(third[:]))
+	var parts []string
+	for i := 0; i < len(colons); i += 2 {
+		parts = append(parts, colons[i:i+2])
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "firmware.txt")
+	content := "# Known compromised keys\n" +
+		hex.EncodeToString(first[:]) + " Router firmware 1.2\n" +
+		base64.StdEncoding.EncodeToString(second[:]) + "\n\n" +
+		strings.Join(parts, ":") + " # vendor advisory\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if b.Len() != 3 {
+		t.Fatalf("Expected 3 fingerprints, got %d", b.Len())
+	}
+
+	testCases := []struct {
+		name        string
+		fingerprint string
+		wantMessage string
+	}{
+		{"HexWithDescription", hex.EncodeToString(first[:]), "Key is in the compromised-key blocklist firmware.txt (Router firmware 1.2)"},
+		{"Base64", hex.EncodeToString(second[:]), "Key is in the compromised-key blocklist firmware.txt"},
+		{"ColonsWithComment", strings.ToUpper(hex.EncodeToString(third[:])), "Key is in the compromised-key blocklist firmware.txt (vendor advisory)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finding := b.Check(tc.fingerprint)
+			if finding == nil {
+				t.Fatal("Expected finding for blocklisted key")
+			}
+			if finding.Severity != "Critical" || finding.Check != "key-blocklist" {
+				t.Errorf("Expected critical key-blocklist finding, got %+v", finding)
+			}
+			if finding.Message != tc.wantMessage {
+				t.Errorf("Expected message %q, got %q", tc.wantMessage, finding.Message)
+			}
+		})
+	}
+
+	unlisted := sha256.Sum256([]byte("unlisted"))
+	if finding := b.Check(hex.EncodeToString(unlisted[:])); finding != nil {
+		t.Errorf("Expected no finding for unlisted key, got %+v", finding)
+	}
+
+	t.Run("InvalidEntry", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad")
+		if err := os.WriteFile(bad, []byte("deadbeef\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write blocklist: %v", err)
+		}
+		if _, err := Load(bad); err == nil {
+			t.Error("Expected error for truncated fingerprint")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package clientcert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Load reads a client certificate and its key for mutual TLS. certFile is a
+// PEM certificate, optionally followed by its chain, or a PKCS#12 bundle,
+// recognised by a .p12 or .pfx extension or by not being PEM. keyFile is
+// the PEM private key and defaults to certFile. password decrypts PKCS#12.
+func Load(certFile, keyFile, password string) (tls.Certificate, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, errors.New("failed to read client certificate: " + err.Error())
+	}
+
+	ext := strings.ToLower(filepath.Ext(certFile))
+	if block, _ := pem.Decode(data); block == nil || ext == ".p12" || ext == ".pfx" {
+		return loadPKCS12(data, password)
+	}
+
+	keyData := data
+	if keyFile != "" {
+		keyData, err = os.ReadFile(keyFile)
+		if err != nil {
+			return tls.Certificate{}, errors.New("failed to read client key: " + err.Error())
+		}
+	}
+	cert, err := tls.X509KeyPair(data, keyData)
+	if err != nil {
+		return tls.Certificate{}, errors.New("invalid client certificate or key: " + err.Error())
+	}
+	return cert, nil
+}
+
+func loadPKCS12(data []byte, password string) (tls.Certificate, error) {
+	key, leaf, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return tls.Certificate{}, errors.New("failed to decode PKCS#12: " + err.Error())
+	}
+	cert := tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert, nil
+}
+
+// Chain parses every certificate of cert, leaf first.
+func Chain(cert tls.Certificate) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+// AcceptableCAs returns the distinguished names a server lists in its
+// CertificateRequest. An empty list means the server accepts any issuer.
+func AcceptableCAs(request *tls.CertificateRequestInfo) []string {
+	var names []string
+	for _, der := range request.AcceptableCAs {
+		var rdns pkix.RDNSequence
+		if rest, err := asn1.Unmarshal(der, &rdns); err != nil || len(rest) > 0 {
+			names = append(names, "(unparseable name)")
+			continue
+		}
+		var name pkix.Name
+		name.FillFromRDNSequence(&rdns)
+		names = append(names, name.String())
+	}
+	return names
+}
+
+// signatureSchemeNames are the IANA names of the signature schemes servers
+// commonly list, including those crypto/tls does not implement.
+var signatureSchemeNames = map[tls.SignatureScheme]string{
+	0x0201: "rsa_pkcs1_sha1",
+	0x0203: "ecdsa_sha1",
+	0x0301: "rsa_pkcs1_sha224",
+	0x0302: "dsa_sha224",
+	0x0303: "ecdsa_sha224",
+	0x0401: "rsa_pkcs1_sha256",
+	0x0402: "dsa_sha256",
+	0x0403: "ecdsa_secp256r1_sha256",
+	0x0501: "rsa_pkcs1_sha384",
+	0x0503: "ecdsa_secp384r1_sha384",
+	0x0601: "rsa_pkcs1_sha512",
+	0x0603: "ecdsa_secp521r1_sha512",
+	0x0804: "rsa_pss_rsae_sha256",
+	0x0805: "rsa_pss_rsae_sha384",
+	0x0806: "rsa_pss_rsae_sha512",
+	0x0807: "ed25519",
+	0x0808: "ed448",
+	0x0809: "rsa_pss_pss_sha256",
+	0x080a: "rsa_pss_pss_sha384",
+	0x080b: "rsa_pss_pss_sha512",
+	0x081a: "ecdsa_brainpoolP256r1tls13_sha256",
+	0x081b: "ecdsa_brainpoolP384r1tls13_sha384",
+	0x081c: "ecdsa_brainpoolP512r1tls13_sha512",
+}
+
+// SignatureSchemes returns the IANA names of the signature algorithms a
+// server accepts for the client's CertificateVerify.
+func SignatureSchemes(request *tls.CertificateRequestInfo) []string {
+	var names []string
+	for _, scheme := range request.SignatureSchemes {
+		name, ok := signatureSchemeNames[scheme]
+		if !ok {
+			name = fmt.Sprintf("0x%04x", uint16(scheme))
+		}
+		names = append(names, name)
+	}
+	return names
+}
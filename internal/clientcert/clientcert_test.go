@@ -0,0 +1,185 @@
+package clientcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func issue(t *testing.T, name string, isCA bool, parent *issued) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Example Corp"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:              []string{"localhost"},
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	issuerCert, issuerKey := template, key
+	if parent != nil {
+		issuerCert, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &issued{cert: cert, key: key}
+}
+
+func writePEM(t *testing.T, path string, blocks ...*pem.Block) {
+	t.Helper()
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ca := issue(t, "Client CA", true, nil)
+	client := issue(t, "client.example", false, ca)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(client.key)
+	certBlock := &pem.Block{Type: "CERTIFICATE", Bytes: client.cert.Raw}
+	caBlock := &pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}
+	keyBlock := &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}
+
+	dir := t.TempDir()
+	separateCert := filepath.Join(dir, "client.crt")
+	separateKey := filepath.Join(dir, "client.key")
+	combined := filepath.Join(dir, "client.pem")
+	writePEM(t, separateCert, certBlock, caBlock)
+	writePEM(t, separateKey, keyBlock)
+	writePEM(t, combined, certBlock, keyBlock)
+
+	pfx, err := pkcs12.Modern.Encode(client.key, client.cert, []*x509.Certificate{ca.cert}, "s3cret")
+	if err != nil {
+		t.Fatalf("Failed to encode PKCS#12: %v", err)
+	}
+	bundle := filepath.Join(dir, "client.p12")
+	os.WriteFile(bundle, pfx, 0o600)
+	unnamed := filepath.Join(dir, "client.bundle")
+	os.WriteFile(unnamed, pfx, 0o600)
+
+	testCases := []struct {
+		name      string
+		certFile  string
+		keyFile   string
+		password  string
+		wantCerts int
+		wantErr   string
+	}{
+		{"SeparatePEM", separateCert, separateKey, "", 2, ""},
+		{"CombinedPEM", combined, "", "", 1, ""},
+		{"PKCS12", bundle, "", "s3cret", 2, ""},
+		{"PKCS12WithoutExtension", unnamed, "", "s3cret", 2, ""},
+		{"PKCS12WrongPassword", bundle, "", "wrong", 0, "failed to decode PKCS#12"},
+		{"MissingKey", separateCert, "", "", 0, "invalid client certificate or key"},
+		{"MissingFile", filepath.Join(dir, "missing.pem"), "", "", 0, "failed to read client certificate"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cert, err := Load(tc.certFile, tc.keyFile, tc.password)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			chain, err := Chain(cert)
+			if err != nil {
+				t.Fatalf("Chain() error: %v", err)
+			}
+			if len(chain) != tc.wantCerts {
+				t.Fatalf("Expected %d certificates, got %d", tc.wantCerts, len(chain))
+			}
+			if chain[0].Subject.CommonName != "client.example" {
+				t.Errorf("Expected the leaf first, got %s", chain[0].Subject.CommonName)
+			}
+		})
+	}
+}
+
+func TestCertificateRequest(t *testing.T) {
+	ca := issue(t, "Client CA", true, nil)
+	server := issue(t, "localhost", false, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.cert.Raw}, PrivateKey: server.key}},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    pool,
+		MaxVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	var request *tls.CertificateRequestInfo
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 2 * time.Second}, "tcp", listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			request = info
+			return &tls.Certificate{}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handshake failed: %v", err)
+	}
+	conn.Close()
+
+	if request == nil {
+		t.Fatal("Expected a CertificateRequest")
+	}
+	cas := AcceptableCAs(request)
+	if len(cas) != 1 || cas[0] != "CN=Client CA,O=Example Corp" {
+		t.Errorf("Expected the client CA's name, got %v", cas)
+	}
+	schemes := SignatureSchemes(request)
+	if len(schemes) == 0 || !strings.Contains(strings.Join(schemes, ","), "ecdsa_secp256r1_sha256") {
+		t.Errorf("Expected ecdsa_secp256r1_sha256 among %v", schemes)
+	}
+}
@@ -1,16 +1,37 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
 type Standard struct {
-	RSA        int `json:"RSA"`
-	ECC        int `json:"ECC"`
-	Symmetric  int `json:"Symmetric"`
-	CutOffYear int `json:"cut_off_year"`
+	Extends       string   `json:"extends,omitempty"`
+	RSA           int      `json:"RSA"`
+	ECC           int      `json:"ECC"`
+	Symmetric     int      `json:"Symmetric"`
+	CutOffYear    int      `json:"cut_off_year"`
+	AllowedCurves []string `json:"allowed_curves,omitempty"`
+	AllowedHashes []string `json:"allowed_hashes,omitempty"`
+	// RequirePSS rejects RSA certificate signatures that use PKCS #1 v1.5
+	// padding. It is opt-in: none of the bundled profiles mandates RSASSA-PSS
+	// for certificates. It is a pointer so an extending standard can switch
+	// it off.
+	RequirePSS  *bool        `json:"require_pss,omitempty"`
+	Transitions []Transition `json:"transitions,omitempty"`
+	Source      string       `json:"source,omitempty"`
+}
+
+// Transition raises a standard's thresholds from Year onwards. Zero values
+// leave the corresponding threshold unchanged.
+type Transition struct {
+	Year      int `json:"year"`
+	RSA       int `json:"RSA,omitempty"`
+	ECC       int `json:"ECC,omitempty"`
+	Symmetric int `json:"Symmetric,omitempty"`
 }
 
 type Standards struct {
@@ -19,7 +40,12 @@ type Standards struct {
 
 type Config struct {
 	SelectedStandard string
-	standards        Standards
+	// Year is the year cut-offs and transitions are evaluated for. Zero
+	// means the current year.
+	Year      int
+	standards Standards
+	layers    []Layer
+	origins   map[string][]string
 }
 
 func NewConfig(standardsFile string, selectedStandard string) (*Config, error) {
@@ -32,9 +58,43 @@ func NewConfig(standardsFile string, selectedStandard string) (*Config, error) {
 		return nil, errors.New("failed to read standards file: " + err.Error())
 	}
 
-	var standards Standards
-	if err := json.Unmarshal(data, &standards); err != nil {
-		return nil, errors.New("failed to parse standards JSON: " + err.Error())
+	layer := Layer{Name: "file", Path: standardsFile, format: FormatFromPath(standardsFile), data: data}
+	return NewLayeredConfig([]Layer{layer}, selectedStandard)
+}
+
+// NewLayeredConfig merges layers in order, so a standard defined in a later
+// layer replaces one of the same name from an earlier layer. Inheritance is
+// resolved after merging, which lets a layer extend standards from another.
+func NewLayeredConfig(layers []Layer, selectedStandard string) (*Config, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("no configuration sources")
+	}
+
+	standards := Standards{Standards: map[string]Standard{}}
+	origins := map[string][]string{}
+	merged := make([]Layer, 0, len(layers))
+	for _, layer := range layers {
+		layerStandards, err := layer.load()
+		if err != nil {
+			return nil, err
+		}
+
+		layer.Standards = make([]string, 0, len(layerStandards.Standards))
+		for name, standard := range layerStandards.Standards {
+			if _, exists := standards.Standards[name]; exists {
+				layer.Overrides = append(layer.Overrides, name)
+			}
+			standards.Standards[name] = standard
+			origins[name] = append(origins[name], layer.Name)
+			layer.Standards = append(layer.Standards, name)
+		}
+		sort.Strings(layer.Standards)
+		sort.Strings(layer.Overrides)
+		merged = append(merged, layer)
+	}
+
+	if err := standards.resolve(); err != nil {
+		return nil, err
 	}
 
 	if selectedStandard == "" {
@@ -47,34 +107,174 @@ func NewConfig(standardsFile string, selectedStandard string) (*Config, error) {
 	return &Config{
 		SelectedStandard: selectedStandard,
 		standards:        standards,
+		layers:           merged,
+		origins:          origins,
 	}, nil
 }
 
+// resolve replaces every standard that extends another with the merged
+// result, so lookups never have to walk the inheritance chain.
+func (s *Standards) resolve() error {
+	names := make([]string, 0, len(s.Standards))
+	for name := range s.Standards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]Standard, len(s.Standards))
+	for _, name := range names {
+		if _, err := s.resolveStandard(name, resolved, nil); err != nil {
+			return err
+		}
+	}
+	s.Standards = resolved
+	return nil
+}
+
+func (s *Standards) resolveStandard(name string, resolved map[string]Standard, chain []string) (Standard, error) {
+	if standard, ok := resolved[name]; ok {
+		return standard, nil
+	}
+	for _, seen := range chain {
+		if seen == name {
+			return Standard{}, errors.New("cyclic standard inheritance: " + strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	standard := s.Standards[name]
+	if standard.Extends == "" {
+		resolved[name] = standard
+		return standard, nil
+	}
+
+	if _, exists := s.Standards[standard.Extends]; !exists {
+		return Standard{}, errors.New("standard " + name + " extends unknown standard: " + standard.Extends)
+	}
+	parent, err := s.resolveStandard(standard.Extends, resolved, append(chain, name))
+	if err != nil {
+		return Standard{}, err
+	}
+
+	merged := parent.merge(standard)
+	resolved[name] = merged
+	return merged, nil
+}
+
+// merge returns s with every field that is set in override replaced.
+func (s Standard) merge(override Standard) Standard {
+	s.Extends = override.Extends
+	if override.RSA != 0 {
+		s.RSA = override.RSA
+	}
+	if override.ECC != 0 {
+		s.ECC = override.ECC
+	}
+	if override.Symmetric != 0 {
+		s.Symmetric = override.Symmetric
+	}
+	if override.CutOffYear != 0 {
+		s.CutOffYear = override.CutOffYear
+	}
+	if override.AllowedCurves != nil {
+		s.AllowedCurves = override.AllowedCurves
+	}
+	if override.AllowedHashes != nil {
+		s.AllowedHashes = override.AllowedHashes
+	}
+	if override.RequirePSS != nil {
+		s.RequirePSS = override.RequirePSS
+	}
+	if override.Transitions != nil {
+		s.Transitions = override.Transitions
+	}
+	if override.Source != "" {
+		s.Source = override.Source
+	}
+	return s
+}
+
 func (c *Config) GetThreshold(algorithm string) int {
+	// A finite-field DH prime is as strong as an RSA modulus of the same size
+	// (SP 800-57 Part 1, Table 2), so DH shares the RSA threshold.
+	if algorithm == "DH" {
+		return c.GetThreshold("RSA")
+	}
+
 	standard := c.standards.Standards[c.SelectedStandard]
-	currentYear := 2025
-	cutOffYear := standard.CutOffYear
+	currentYear := c.currentYear()
 
 	threshold := 0
 	switch algorithm {
 	case "RSA":
 		threshold = standard.RSA
-		if currentYear > cutOffYear {
-			threshold = 3072
-		}
 	case "ECC":
 		threshold = standard.ECC
 	case "Symmetric":
 		threshold = standard.Symmetric
 	}
 
+	for _, t := range standard.Transitions {
+		if t.Year > currentYear {
+			continue
+		}
+		switch {
+		case algorithm == "RSA" && t.RSA > threshold:
+			threshold = t.RSA
+		case algorithm == "ECC" && t.ECC > threshold:
+			threshold = t.ECC
+		case algorithm == "Symmetric" && t.Symmetric > threshold:
+			threshold = t.Symmetric
+		}
+	}
+
 	return threshold
 }
 
+func (c *Config) currentYear() int {
+	if c.Year != 0 {
+		return c.Year
+	}
+	return time.Now().Year()
+}
+
+func (c *Config) GetStandard() Standard {
+	return c.standards.Standards[c.SelectedStandard]
+}
+
+// Standard looks up any loaded standard by name.
+func (c *Config) Standard(name string) (Standard, bool) {
+	standard, ok := c.standards.Standards[name]
+	return standard, ok
+}
+
 func (c *Config) AvailableStandards() []string {
 	standards := make([]string, 0, len(c.standards.Standards))
 	for name := range c.standards.Standards {
 		standards = append(standards, name)
 	}
+	sort.Strings(standards)
 	return standards
 }
+
+// Layers returns the configuration sources in the order they were merged.
+func (c *Config) Layers() []Layer {
+	return c.layers
+}
+
+// Warnings returns the cut-off years before Year in every layer the user
+// supplied, prefixed with the layer they were raised in, in merge order.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	for _, layer := range c.layers {
+		for _, issue := range layer.cutOffWarnings(c.currentYear()) {
+			warnings = append(warnings, layer.describe()+": "+issue.Error())
+		}
+	}
+	return warnings
+}
+
+// Origins returns the names of the layers that defined the standard, in merge
+// order. The last entry is the definition in effect.
+func (c *Config) Origins(standard string) []string {
+	return c.origins[standard]
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -109,9 +110,127 @@ func TestNewConfig(t *testing.T) {
 	}
 }
 
+func TestNewConfigInheritance(t *testing.T) {
+	tests := []struct {
+		name             string
+		standardsJSON    string
+		selectedStandard string
+		want             Standard
+		errorMsg         string
+	}{
+		{
+			name: "Partial override of parent",
+			standardsJSON: `{"standards": {
+				"BSI": {"RSA": 3072, "ECC": 256, "Symmetric": 128, "cut_off_year": 2030, "allowed_curves": ["P-256", "P-384"], "source": "TR-02102-1"},
+				"Internal": {"extends": "BSI", "RSA": 4096, "allowed_curves": ["P-384"]}
+			}}`,
+			selectedStandard: "Internal",
+			want: Standard{
+				Extends:       "BSI",
+				RSA:           4096,
+				ECC:           256,
+				Symmetric:     128,
+				CutOffYear:    2030,
+				AllowedCurves: []string{"P-384"},
+				Source:        "TR-02102-1",
+			},
+		},
+		{
+			name: "Multi-level inheritance",
+			standardsJSON: `{"standards": {
+				"Base": {"RSA": 2048, "ECC": 256, "Symmetric": 128, "cut_off_year": 2031},
+				"Middle": {"extends": "Base", "ECC": 384},
+				"Leaf": {"extends": "Middle", "Symmetric": 256}
+			}}`,
+			selectedStandard: "Leaf",
+			want: Standard{
+				Extends:    "Middle",
+				RSA:        2048,
+				ECC:        384,
+				Symmetric:  256,
+				CutOffYear: 2031,
+			},
+		},
+		{
+			name: "Child switches off inherited PSS requirement",
+			standardsJSON: `{"standards": {
+				"Strict": {"RSA": 3072, "ECC": 256, "Symmetric": 128, "cut_off_year": 2030, "require_pss": true},
+				"Relaxed": {"extends": "Strict", "require_pss": false}
+			}}`,
+			selectedStandard: "Relaxed",
+			want: Standard{
+				Extends:    "Strict",
+				RSA:        3072,
+				ECC:        256,
+				Symmetric:  128,
+				CutOffYear: 2030,
+				RequirePSS: new(bool),
+			},
+		},
+		{
+			name: "Unknown parent",
+			standardsJSON: `{"standards": {
+				"Internal": {"extends": "Missing", "RSA": 4096}
+			}}`,
+			selectedStandard: "Internal",
+			errorMsg:         "standard Internal extends unknown standard: Missing",
+		},
+		{
+			name: "Inheritance cycle",
+			standardsJSON: `{"standards": {
+				"A": {"extends": "B"},
+				"B": {"extends": "C"},
+				"C": {"extends": "A"}
+			}}`,
+			selectedStandard: "A",
+			errorMsg:         "cyclic standard inheritance: A -> B -> C -> A",
+		},
+		{
+			name: "Self reference",
+			standardsJSON: `{"standards": {
+				"A": {"extends": "A"}
+			}}`,
+			selectedStandard: "A",
+			errorMsg:         "cyclic standard inheritance: A -> A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile, err := os.CreateTemp("", "standards-*.json")
+			if err != nil {
+				t.Fatal("Failed to create temp file:", err)
+			}
+			defer os.Remove(tempFile.Name())
+			if _, err := tempFile.WriteString(tt.standardsJSON); err != nil {
+				t.Fatal("Failed to write to temp file:", err)
+			}
+			tempFile.Close()
+
+			cfg, err := NewConfig(tempFile.Name(), tt.selectedStandard)
+			if tt.errorMsg != "" {
+				if err == nil {
+					t.Fatalf("Expected error %q but got nil", tt.errorMsg)
+				}
+				if err.Error() != tt.errorMsg {
+					t.Errorf("Expected error %q, got %q", tt.errorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := cfg.GetStandard(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStandard() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetThreshold(t *testing.T) {
 	cfg := &Config{
 		SelectedStandard: "TestStandard",
+		Year:             2025,
 		standards: Standards{
 			Standards: map[string]Standard{
 				"TestStandard": {
@@ -126,6 +245,16 @@ func TestGetThreshold(t *testing.T) {
 					Symmetric:  128,
 					CutOffYear: 2020,
 				},
+				"TransitionedStandard": {
+					RSA:        2048,
+					ECC:        256,
+					Symmetric:  112,
+					CutOffYear: 2030,
+					Transitions: []Transition{
+						{Year: 2020, ECC: 384, Symmetric: 128},
+						{Year: 2099, RSA: 15360},
+					},
+				},
 			},
 		},
 	}
@@ -143,10 +272,10 @@ func TestGetThreshold(t *testing.T) {
 			wantThreshold: 2048,
 		},
 		{
-			name:          "RSA threshold with expired cutoff year",
+			name:          "RSA threshold unchanged by expired cutoff year",
 			standard:      "OldStandard",
 			algorithm:     "RSA",
-			wantThreshold: 3072,
+			wantThreshold: 2048,
 		},
 		{
 			name:          "ECC threshold",
@@ -160,6 +289,30 @@ func TestGetThreshold(t *testing.T) {
 			algorithm:     "Symmetric",
 			wantThreshold: 128,
 		},
+		{
+			name:          "ECC threshold raised by past transition",
+			standard:      "TransitionedStandard",
+			algorithm:     "ECC",
+			wantThreshold: 384,
+		},
+		{
+			name:          "Symmetric threshold raised by past transition",
+			standard:      "TransitionedStandard",
+			algorithm:     "Symmetric",
+			wantThreshold: 128,
+		},
+		{
+			name:          "RSA threshold ignores future transition",
+			standard:      "TransitionedStandard",
+			algorithm:     "RSA",
+			wantThreshold: 2048,
+		},
+		{
+			name:          "DH threshold follows RSA",
+			standard:      "OldStandard",
+			algorithm:     "DH",
+			wantThreshold: 2048,
+		},
 		{
 			name:          "Unknown algorithm",
 			standard:      "TestStandard",
@@ -179,6 +332,91 @@ func TestGetThreshold(t *testing.T) {
 	}
 }
 
+func TestGetThresholdTransitionYear(t *testing.T) {
+	cfg := &Config{
+		SelectedStandard: "TestStandard",
+		standards: Standards{
+			Standards: map[string]Standard{
+				"TestStandard": {
+					RSA:        2048,
+					ECC:        256,
+					Symmetric:  112,
+					CutOffYear: 2030,
+					Transitions: []Transition{
+						{Year: 2028, ECC: 384, Symmetric: 128},
+						{Year: 2031, RSA: 3072},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		year          int
+		wantRSA       int
+		wantECC       int
+		wantSymmetric int
+	}{
+		{year: 2027, wantRSA: 2048, wantECC: 256, wantSymmetric: 112},
+		{year: 2028, wantRSA: 2048, wantECC: 384, wantSymmetric: 128},
+		{year: 2030, wantRSA: 2048, wantECC: 384, wantSymmetric: 128},
+		{year: 2031, wantRSA: 3072, wantECC: 384, wantSymmetric: 128},
+	}
+
+	for _, tt := range tests {
+		cfg.Year = tt.year
+		if got := cfg.GetThreshold("RSA"); got != tt.wantRSA {
+			t.Errorf("Year %d: GetThreshold(RSA) = %d, want %d", tt.year, got, tt.wantRSA)
+		}
+		if got := cfg.GetThreshold("ECC"); got != tt.wantECC {
+			t.Errorf("Year %d: GetThreshold(ECC) = %d, want %d", tt.year, got, tt.wantECC)
+		}
+		if got := cfg.GetThreshold("Symmetric"); got != tt.wantSymmetric {
+			t.Errorf("Year %d: GetThreshold(Symmetric) = %d, want %d", tt.year, got, tt.wantSymmetric)
+		}
+	}
+}
+
+func TestBundledStandards(t *testing.T) {
+	cfg, err := NewConfig("../../data/standards.json", "")
+	if err != nil {
+		t.Fatalf("Failed to load bundled standards: %v", err)
+	}
+
+	for _, name := range []string{"NIST", "IETF", "BSI", "ANSSI", "ENISA", "ECRYPT-CSA", "ECRYPT-CSA-longterm", "CNSA2", "PCI-DSS", "CABF", "FIPS-140-3"} {
+		t.Run(name, func(t *testing.T) {
+			cfg.SelectedStandard = name
+			standard := cfg.GetStandard()
+			if standard.Source == "" {
+				t.Errorf("Standard %q has no source citation", name)
+			}
+			if standard.RSA == 0 || standard.ECC == 0 || standard.Symmetric == 0 {
+				t.Errorf("Standard %q is missing a threshold: %+v", name, standard)
+			}
+			if len(standard.AllowedCurves) == 0 || len(standard.AllowedHashes) == 0 {
+				t.Errorf("Standard %q has no allowed curves or hashes", name)
+			}
+		})
+	}
+}
+
+// TestBundledRSATransitions checks that the RSA threshold only rises past the
+// cut-off year where a profile's source calls for it.
+func TestBundledRSATransitions(t *testing.T) {
+	cfg, err := NewConfig("../../data/standards.json", "")
+	if err != nil {
+		t.Fatalf("Failed to load bundled standards: %v", err)
+	}
+	cfg.Year = 2035
+
+	for name, want := range map[string]int{"NIST": 3072, "ANSSI": 3072, "FIPS-140-3": 3072, "IETF": 2048, "PCI-DSS": 2048, "CABF": 2048} {
+		cfg.SelectedStandard = name
+		if got := cfg.GetThreshold("RSA"); got != want {
+			t.Errorf("%s in %d: GetThreshold(RSA) = %d, want %d", name, cfg.Year, got, want)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr
 }
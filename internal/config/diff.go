@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is one named setting of a standard rendered for display.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Difference is a field whose value differs between two standards.
+type Difference struct {
+	Field string
+	Left  string
+	Right string
+}
+
+// Fields lists every setting of the standard in a stable order. Unset values
+// are rendered as "-".
+func (s Standard) Fields() []Field {
+	fields := []Field{
+		{"Extends", orDash(s.Extends)},
+		{"RSA", formatBits(s.RSA)},
+		{"ECC", formatBits(s.ECC)},
+		{"Symmetric", formatBits(s.Symmetric)},
+		{"Cut-off Year", formatYear(s.CutOffYear)},
+		{"Allowed Curves", orDash(strings.Join(s.AllowedCurves, ", "))},
+		{"Allowed Hashes", orDash(strings.Join(s.AllowedHashes, ", "))},
+		{"Require PSS", formatBool(s.RequirePSS)},
+	}
+	for _, t := range s.Transitions {
+		fields = append(fields, Field{"Transition " + strconv.Itoa(t.Year), t.String()})
+	}
+	return append(fields, Field{"Source", orDash(s.Source)})
+}
+
+func (t Transition) String() string {
+	var parts []string
+	if t.RSA != 0 {
+		parts = append(parts, fmt.Sprintf("RSA %d", t.RSA))
+	}
+	if t.ECC != 0 {
+		parts = append(parts, fmt.Sprintf("ECC %d", t.ECC))
+	}
+	if t.Symmetric != 0 {
+		parts = append(parts, fmt.Sprintf("Symmetric %d", t.Symmetric))
+	}
+	return orDash(strings.Join(parts, ", "))
+}
+
+// DiffStandards returns the fields that differ between left and right, in the
+// order of Standard.Fields. A field present on one side only is compared
+// against "-".
+func DiffStandards(left, right Standard) []Difference {
+	leftFields := left.Fields()
+	rightValues := map[string]string{}
+	for _, f := range right.Fields() {
+		rightValues[f.Name] = f.Value
+	}
+
+	var diffs []Difference
+	seen := map[string]bool{}
+	for _, f := range leftFields {
+		seen[f.Name] = true
+		rightValue, ok := rightValues[f.Name]
+		if !ok {
+			rightValue = "-"
+		}
+		if f.Value != rightValue {
+			diffs = append(diffs, Difference{Field: f.Name, Left: f.Value, Right: rightValue})
+		}
+	}
+	for _, f := range right.Fields() {
+		if !seen[f.Name] {
+			diffs = append(diffs, Difference{Field: f.Name, Left: "-", Right: f.Value})
+		}
+	}
+	return diffs
+}
+
+func formatBits(bits int) string {
+	if bits == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d bits", bits)
+}
+
+func formatYear(year int) string {
+	if year == 0 {
+		return "-"
+	}
+	return strconv.Itoa(year)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatBool(b *bool) string {
+	switch {
+	case b == nil:
+		return "-"
+	case *b:
+		return "yes"
+	}
+	return "no"
+}
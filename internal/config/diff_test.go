@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffStandards(t *testing.T) {
+	nist := Standard{
+		RSA:           2048,
+		ECC:           256,
+		Symmetric:     128,
+		CutOffYear:    2031,
+		AllowedCurves: []string{"P-256", "P-384"},
+		Transitions:   []Transition{{Year: 2031, RSA: 3072}},
+		Source:        "SP 800-57",
+	}
+
+	tests := []struct {
+		name  string
+		left  Standard
+		right Standard
+		want  []Difference
+	}{
+		{
+			name:  "Identical",
+			left:  nist,
+			right: nist,
+		},
+		{
+			name: "Thresholds and lists",
+			left: nist,
+			right: Standard{
+				RSA:           3072,
+				ECC:           384,
+				Symmetric:     128,
+				CutOffYear:    2031,
+				AllowedCurves: []string{"P-384"},
+				Transitions:   []Transition{{Year: 2031, RSA: 3072}, {Year: 2035, ECC: 521}},
+				Source:        "SP 800-57",
+			},
+			want: []Difference{
+				{Field: "RSA", Left: "2048 bits", Right: "3072 bits"},
+				{Field: "ECC", Left: "256 bits", Right: "384 bits"},
+				{Field: "Allowed Curves", Left: "P-256, P-384", Right: "P-384"},
+				{Field: "Transition 2035", Left: "-", Right: "ECC 521"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffStandards(tt.left, tt.right); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffStandards() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Position is a 1-based line and column in a standards file.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// document is a decoded standards file together with the position of every
+// key, indexed by JSON pointer, so schema errors can point back at the source.
+type document struct {
+	value     any
+	positions map[string]Position
+}
+
+// FormatFromPath picks the file format from the extension, defaulting to JSON.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+func parseDocument(data []byte, format string) (*document, error) {
+	switch format {
+	case FormatYAML:
+		return parseYAML(data)
+	case FormatTOML:
+		return parseTOML(data)
+	case FormatJSON, "":
+		return parseJSON(data)
+	default:
+		return nil, errors.New("unsupported standards format: " + format)
+	}
+}
+
+// position returns the location of ptr, falling back to its closest ancestor.
+func (d *document) position(ptr string) Position {
+	for {
+		if pos, ok := d.positions[ptr]; ok {
+			return pos
+		}
+		i := strings.LastIndex(ptr, "/")
+		if i < 0 {
+			return Position{}
+		}
+		ptr = ptr[:i]
+	}
+}
+
+// decode converts the generic document into Standards.
+func (d *document) decode() (Standards, error) {
+	var standards Standards
+	data, err := json.Marshal(d.value)
+	if err != nil {
+		return standards, err
+	}
+	err = json.Unmarshal(data, &standards)
+	return standards, err
+}
+
+func pointer(parent, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return parent + "/" + token
+}
+
+func offsetPosition(data []byte, offset int) Position {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return Position{Line: line, Column: column}
+}
+
+func parseJSON(data []byte) (*document, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// Offset counts the offending byte, so step back onto it.
+			offset := max(int(syntaxErr.Offset)-1, 0)
+			return nil, &Issue{Position: offsetPosition(data, offset), Message: err.Error()}
+		}
+		return nil, err
+	}
+
+	doc := &document{value: value, positions: map[string]Position{}}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := doc.walkJSON(dec, data, ""); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// walkJSON records the position of every value below ptr. Object members are
+// recorded at their key rather than their value.
+func (d *document) walkJSON(dec *json.Decoder, data []byte, ptr string) error {
+	start := jsonTokenStart(data, dec.InputOffset())
+	d.positions[ptr] = offsetPosition(data, start)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			keyStart := jsonTokenStart(data, dec.InputOffset())
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			child := pointer(ptr, keyTok.(string))
+			if err := d.walkJSON(dec, data, child); err != nil {
+				return err
+			}
+			d.positions[child] = offsetPosition(data, keyStart)
+		}
+		_, err = dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := d.walkJSON(dec, data, pointer(ptr, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	}
+	return err
+}
+
+func jsonTokenStart(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func parseYAML(data []byte) (*document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &Issue{Message: err.Error()}
+	}
+
+	doc := &document{positions: map[string]Position{}}
+	if len(root.Content) == 0 {
+		return doc, nil
+	}
+	if err := root.Content[0].Decode(&doc.value); err != nil {
+		return nil, &Issue{Position: Position{Line: root.Line, Column: root.Column}, Message: err.Error()}
+	}
+	doc.walkYAML(root.Content[0], "")
+	return doc, nil
+}
+
+func (d *document) walkYAML(node *yaml.Node, ptr string) {
+	if _, exists := d.positions[ptr]; !exists {
+		d.positions[ptr] = Position{Line: node.Line, Column: node.Column}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := pointer(ptr, key.Value)
+			d.positions[child] = Position{Line: key.Line, Column: key.Column}
+			d.walkYAML(value, child)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			d.walkYAML(item, pointer(ptr, strconv.Itoa(i)))
+		}
+	}
+}
+
+func parseTOML(data []byte) (*document, error) {
+	var value map[string]any
+	if err := toml.Unmarshal(data, &value); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return nil, &Issue{Position: Position{Line: line, Column: column}, Message: decodeErr.Error()}
+		}
+		return nil, &Issue{Message: err.Error()}
+	}
+
+	doc := &document{value: value, positions: map[string]Position{}}
+	p := unstable.Parser{}
+	p.Reset(data)
+
+	arrayTables := map[string]int{}
+	table := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table:
+			table = doc.tomlKey(&p, "", expr.Key())
+		case unstable.ArrayTable:
+			path := doc.tomlKey(&p, "", expr.Key())
+			table = pointer(path, strconv.Itoa(arrayTables[path]))
+			arrayTables[path]++
+			doc.positions[table] = doc.positions[path]
+		case unstable.KeyValue:
+			doc.walkTOML(&p, doc.tomlKey(&p, table, expr.Key()), expr.Value())
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, &Issue{Message: err.Error()}
+	}
+	return doc, nil
+}
+
+// tomlKey records every part of a dotted key below parent and returns the
+// pointer of the last part.
+func (d *document) tomlKey(p *unstable.Parser, parent string, key unstable.Iterator) string {
+	ptr := parent
+	for key.Next() {
+		part := key.Node()
+		ptr = pointer(ptr, string(part.Data))
+		if _, exists := d.positions[ptr]; !exists {
+			shape := p.Shape(part.Raw)
+			d.positions[ptr] = Position{Line: shape.Start.Line, Column: shape.Start.Column}
+		}
+	}
+	return ptr
+}
+
+func (d *document) walkTOML(p *unstable.Parser, ptr string, value *unstable.Node) {
+	switch value.Kind {
+	case unstable.Array:
+		children := value.Children()
+		for i := 0; children.Next(); {
+			child := children.Node()
+			if child.Kind == unstable.Comment {
+				continue
+			}
+			item := pointer(ptr, strconv.Itoa(i))
+			shape := p.Shape(child.Raw)
+			d.positions[item] = Position{Line: shape.Start.Line, Column: shape.Start.Column}
+			d.walkTOML(p, item, child)
+			i++
+		}
+	case unstable.InlineTable:
+		children := value.Children()
+		for children.Next() {
+			child := children.Node()
+			if child.Kind == unstable.KeyValue {
+				d.walkTOML(p, d.tomlKey(p, ptr, child.Key()), child.Value())
+			}
+		}
+	}
+}
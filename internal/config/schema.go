@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Horiodino/key-length/data"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const schemaURL = "https://github.com/Horiodino/key-length/data/standards.schema.json"
+
+// Issue is a problem found while validating a standards file. Warnings do not
+// prevent the file from being loaded.
+type Issue struct {
+	Position
+	Path    string
+	Message string
+	Warning bool
+}
+
+func (i *Issue) Error() string {
+	var sb strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&sb, "%d:%d: ", i.Line, i.Column)
+	}
+	if i.Path != "" {
+		sb.WriteString(i.Path + ": ")
+	}
+	sb.WriteString(i.Message)
+	return sb.String()
+}
+
+var (
+	schemaOnce     sync.Once
+	compiledSchema *jsonschema.Schema
+	schemaErr      error
+)
+
+func standardsSchema() (*jsonschema.Schema, error) {
+	schemaOnce.Do(func() {
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data.Schema))
+		if err != nil {
+			schemaErr = err
+			return
+		}
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource(schemaURL, doc); err != nil {
+			schemaErr = err
+			return
+		}
+		compiledSchema, schemaErr = c.Compile(schemaURL)
+	})
+	return compiledSchema, schemaErr
+}
+
+// ValidateFile checks a standards file against the published schema. A file
+// that cannot be parsed is reported as a single issue.
+func ValidateFile(path string) ([]Issue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateData(content, FormatFromPath(path))
+}
+
+// ValidateData checks a standards document in the given format against the
+// published schema and flags cut-off years that have already passed.
+func ValidateData(content []byte, format string) ([]Issue, error) {
+	doc, err := parseDocument(content, format)
+	if err != nil {
+		if issue, ok := err.(*Issue); ok {
+			return []Issue{*issue}, nil
+		}
+		return nil, err
+	}
+	return doc.validate()
+}
+
+func (d *document) validate() ([]Issue, error) {
+	schema, err := standardsSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through JSON so every format hands the validator the same
+	// value types.
+	raw, err := json.Marshal(d.value)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if err := schema.Validate(instance); err != nil {
+		validationErr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, err
+		}
+		issues = append(issues, d.schemaIssues(validationErr)...)
+	}
+	issues = append(issues, d.cutOffWarnings(time.Now().Year())...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues, nil
+}
+
+func (d *document) schemaIssues(err *jsonschema.ValidationError) []Issue {
+	if len(err.Causes) > 0 {
+		var issues []Issue
+		for _, cause := range err.Causes {
+			issues = append(issues, d.schemaIssues(cause)...)
+		}
+		return issues
+	}
+
+	ptr := ""
+	for _, token := range err.InstanceLocation {
+		ptr = pointer(ptr, token)
+	}
+
+	// Point unknown keys at the key itself rather than the enclosing object.
+	if additional, ok := err.ErrorKind.(*kind.AdditionalProperties); ok {
+		issues := make([]Issue, 0, len(additional.Properties))
+		for _, property := range additional.Properties {
+			keyPtr := pointer(ptr, property)
+			issues = append(issues, Issue{
+				Position: d.position(keyPtr),
+				Path:     keyPtr,
+				Message:  "unknown key " + strconv.Quote(property),
+			})
+		}
+		return issues
+	}
+
+	return []Issue{{
+		Position: d.position(ptr),
+		Path:     ptr,
+		Message:  err.ErrorKind.LocalizedString(message.NewPrinter(language.English)),
+	}}
+}
+
+// cutOffWarnings flags every cut-off year before year.
+func (d *document) cutOffWarnings(year int) []Issue {
+	root, ok := d.value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	standards, ok := root["standards"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var issues []Issue
+	for name, value := range standards {
+		standard, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		cutOff, ok := toInt(standard["cut_off_year"])
+		if !ok || cutOff >= year {
+			continue
+		}
+		ptr := pointer(pointer("/standards", name), "cut_off_year")
+		issues = append(issues, Issue{
+			Position: d.position(ptr),
+			Path:     ptr,
+			Message:  fmt.Sprintf("cut-off year %d is in the past", cutOff),
+			Warning:  true,
+		})
+	}
+	return issues
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float64:
+		return int(n), n == float64(int(n))
+	}
+	return 0, false
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConfigFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "YAML",
+			file: "standards.yaml",
+			content: `# Reviewed by the security team.
+standards:
+  Internal:
+    extends: BSI
+    RSA: 4096   # CA keys
+    allowed_curves: [P-384, P-521]
+  BSI:
+    RSA: 3072
+    ECC: 256
+    Symmetric: 128
+    cut_off_year: 2030
+`,
+		},
+		{
+			name: "TOML",
+			file: "standards.toml",
+			content: `# Reviewed by the security team.
+[standards.Internal]
+extends = "BSI"
+RSA = 4096 # CA keys
+allowed_curves = ["P-384", "P-521"]
+
+[standards.BSI]
+RSA = 3072
+ECC = 256
+Symmetric = 128
+cut_off_year = 2030
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal("Failed to write standards file:", err)
+			}
+
+			cfg, err := NewConfig(path, "Internal")
+			if err != nil {
+				t.Fatalf("NewConfig() error: %v", err)
+			}
+			if got := cfg.GetThreshold("RSA"); got != 4096 {
+				t.Errorf("RSA threshold = %d, want 4096", got)
+			}
+			if got := cfg.GetThreshold("ECC"); got != 256 {
+				t.Errorf("ECC threshold = %d, want 256", got)
+			}
+			if got := cfg.GetStandard().AllowedCurves; len(got) != 2 || got[0] != "P-384" {
+				t.Errorf("AllowedCurves = %v, want [P-384 P-521]", got)
+			}
+		})
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	type wantIssue struct {
+		line, column int
+		path         string
+		warning      bool
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []wantIssue
+	}{
+		{
+			name:   "Valid JSON",
+			format: FormatJSON,
+			content: `{"standards": {
+  "NIST": {"RSA": 2048, "ECC": 256, "Symmetric": 128, "cut_off_year": 2099}
+}}`,
+		},
+		{
+			name:   "JSON unknown key and negative threshold",
+			format: FormatJSON,
+			content: `{"standards": {
+  "NIST": {
+    "RSA": -1,
+    "ECC": 256,
+    "Symetric": 128
+  }
+}}`,
+			want: []wantIssue{
+				{line: 3, column: 5, path: "/standards/NIST/RSA"},
+				{line: 5, column: 5, path: "/standards/NIST/Symetric"},
+			},
+		},
+		{
+			name:   "YAML past cut-off year",
+			format: FormatYAML,
+			content: `standards:
+  Legacy:
+    RSA: 1024
+    cut_off_year: 2010
+`,
+			want: []wantIssue{
+				{line: 4, column: 5, path: "/standards/Legacy/cut_off_year", warning: true},
+			},
+		},
+		{
+			name:   "TOML unknown key in array table",
+			format: FormatTOML,
+			content: `[standards.Internal]
+RSA = 4096
+
+[[standards.Internal.transitions]]
+year = 2031
+RSA = 4096
+
+[[standards.Internal.transitions]]
+year = 2035
+DH = 4096
+`,
+			want: []wantIssue{
+				{line: 10, column: 1, path: "/standards/Internal/transitions/1/DH"},
+			},
+		},
+		{
+			name:    "JSON syntax error",
+			format:  FormatJSON,
+			content: "{\"standards\": {\n  \"NIST\": {\"RSA\": 2048,}\n}}",
+			want: []wantIssue{
+				{line: 2, column: 24},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := ValidateData([]byte(tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("ValidateData() error: %v", err)
+			}
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Got %d issues, want %d: %v", len(issues), len(tt.want), issues)
+			}
+			for i, want := range tt.want {
+				got := issues[i]
+				if got.Line != want.line || got.Column != want.column || got.Path != want.path || got.Warning != want.warning {
+					t.Errorf("Issue %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewConfigRejectsInvalidSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	content := "standards:\n  NIST:\n    RSA: 2048\n    ECC: -256\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal("Failed to write standards file:", err)
+	}
+
+	_, err := NewConfig(path, "NIST")
+	if err == nil {
+		t.Fatal("Expected error for negative threshold")
+	}
+	if !contains(err.Error(), "invalid standards in file") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestEmbeddedStandardsAreValid(t *testing.T) {
+	issues, err := ValidateData(EmbeddedLayer().data, FormatJSON)
+	if err != nil {
+		t.Fatalf("ValidateData() error: %v", err)
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			t.Errorf("Embedded standards issue: %v", issue.Error())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Horiodino/key-length/data"
+)
+
+// EnvConfig names the environment variable that points at a standards file.
+const EnvConfig = "KEYLENGTH_CONFIG"
+
+const appConfigDir = "keylength"
+
+const embeddedLayerName = "embedded"
+
+var (
+	configFileNames  = []string{"standards.json", "standards.yaml", "standards.yml", "standards.toml"}
+	systemConfigDirs = []string{"/etc/keylength"}
+)
+
+// Layer is one configuration source merged into a Config.
+type Layer struct {
+	Name      string
+	Path      string
+	Standards []string
+	Overrides []string
+	format    string
+	data      []byte
+}
+
+func (l Layer) describe() string {
+	if l.Path == "" {
+		return l.Name
+	}
+	return l.Name + " (" + l.Path + ")"
+}
+
+// load parses and validates the layer. Schema warnings do not stop it from
+// loading.
+func (l Layer) load() (Standards, error) {
+	doc, err := parseDocument(l.data, l.format)
+	if err != nil {
+		return Standards{}, errors.New("failed to parse standards from " + l.describe() + ": " + err.Error())
+	}
+
+	issues, err := doc.validate()
+	if err != nil {
+		return Standards{}, errors.New("failed to validate standards from " + l.describe() + ": " + err.Error())
+	}
+	var problems []string
+	for _, issue := range issues {
+		if !issue.Warning {
+			problems = append(problems, issue.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return Standards{}, errors.New("invalid standards in " + l.describe() + ": " + strings.Join(problems, "; "))
+	}
+
+	standards, err := doc.decode()
+	if err != nil {
+		return Standards{}, errors.New("failed to decode standards from " + l.describe() + ": " + err.Error())
+	}
+	return standards, nil
+}
+
+// cutOffWarnings flags the cut-off years of the layer before year. The
+// embedded layer never warns: its profiles ship with the binary, so the
+// user could not act on the warning.
+func (l Layer) cutOffWarnings(year int) []Issue {
+	if l.Name == embeddedLayerName && l.Path == "" {
+		return nil
+	}
+	doc, err := parseDocument(l.data, l.format)
+	if err != nil {
+		return nil
+	}
+	return doc.cutOffWarnings(year)
+}
+
+// EmbeddedLayer returns the standards compiled into the binary.
+func EmbeddedLayer() Layer {
+	return Layer{Name: embeddedLayerName, format: FormatJSON, data: data.Standards}
+}
+
+// DiscoverLayers returns every available configuration source, lowest
+// priority first: the embedded defaults, system-wide files, the user's XDG
+// config directory, $KEYLENGTH_CONFIG and finally flagPath. Files named
+// explicitly through the environment or flagPath must exist.
+func DiscoverLayers(flagPath string) ([]Layer, error) {
+	layers := []Layer{EmbeddedLayer()}
+
+	for _, dir := range systemDirs() {
+		layer, found, err := findLayer("system", dir)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			layers = append(layers, layer)
+		}
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		layer, found, err := findLayer("user", filepath.Join(dir, appConfigDir))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			layers = append(layers, layer)
+		}
+	}
+
+	if path := os.Getenv(EnvConfig); path != "" {
+		layer, err := readLayer("env "+EnvConfig, path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	if flagPath != "" {
+		layer, err := readLayer("flag --config", flagPath)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+// systemDirs lists system configuration directories, lowest priority first.
+// $XDG_CONFIG_DIRS is ordered by preference, so it is walked in reverse.
+func systemDirs() []string {
+	dirs := append([]string{}, systemConfigDirs...)
+
+	xdgDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if xdgDirs == "" {
+		xdgDirs = "/etc/xdg"
+	}
+	parts := strings.Split(xdgDirs, string(os.PathListSeparator))
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != "" {
+			dirs = append(dirs, filepath.Join(parts[i], appConfigDir))
+		}
+	}
+	return dirs
+}
+
+func findLayer(name, dir string) (Layer, bool, error) {
+	for _, fileName := range configFileNames {
+		path := filepath.Join(dir, fileName)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		layer, err := readLayer(name, path)
+		return layer, err == nil, err
+	}
+	return Layer{}, false, nil
+}
+
+// FileLayer reads a standards file given on the command line.
+func FileLayer(path string) (Layer, error) {
+	return readLayer("file", path)
+}
+
+func readLayer(name, path string) (Layer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Layer{}, errors.New("failed to read standards file from " + name + ": " + err.Error())
+	}
+	return Layer{Name: name, Path: path, format: FormatFromPath(path), data: data}, nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeStandardsFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal("Failed to create config dir:", err)
+	}
+	path := filepath.Join(dir, "standards.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal("Failed to write standards file:", err)
+	}
+	return path
+}
+
+func TestDiscoverLayers(t *testing.T) {
+	savedDirs := systemConfigDirs
+	systemConfigDirs = nil
+	defer func() { systemConfigDirs = savedDirs }()
+
+	root := t.TempDir()
+	writeStandardsFile(t, filepath.Join(root, "xdg", "keylength"),
+		`{"standards": {"Fleet": {"extends": "NIST", "ECC": 384}}}`)
+	writeStandardsFile(t, filepath.Join(root, "home", "keylength"),
+		`{"standards": {"NIST": {"RSA": 3072, "ECC": 256, "Symmetric": 128, "cut_off_year": 2031}}}`)
+	envPath := writeStandardsFile(t, filepath.Join(root, "env"),
+		`{"standards": {"Internal": {"extends": "BSI", "RSA": 4096}}}`)
+
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(root, "xdg"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "home"))
+	t.Setenv(EnvConfig, envPath)
+
+	layers, err := DiscoverLayers("")
+	if err != nil {
+		t.Fatalf("DiscoverLayers() error: %v", err)
+	}
+
+	var names []string
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	wantNames := []string{"embedded", "system", "user", "env " + EnvConfig}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("Layer names = %v, want %v", names, wantNames)
+	}
+
+	cfg, err := NewLayeredConfig(layers, "Internal")
+	if err != nil {
+		t.Fatalf("NewLayeredConfig() error: %v", err)
+	}
+	if got := cfg.GetThreshold("RSA"); got != 4096 {
+		t.Errorf("Internal RSA threshold = %d, want 4096", got)
+	}
+
+	cfg.SelectedStandard = "Fleet"
+	if got := cfg.GetThreshold("RSA"); got != 3072 {
+		t.Errorf("Fleet should inherit the user's NIST override, got RSA threshold %d", got)
+	}
+	if got := cfg.GetThreshold("ECC"); got != 384 {
+		t.Errorf("Fleet ECC threshold = %d, want 384", got)
+	}
+
+	if got, want := cfg.Origins("NIST"), []string{"embedded", "user"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Origins(NIST) = %v, want %v", got, want)
+	}
+	if got, want := cfg.Layers()[2].Overrides, []string{"NIST"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("user layer overrides = %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverLayersMissingExplicitFile(t *testing.T) {
+	savedDirs := systemConfigDirs
+	systemConfigDirs = nil
+	defer func() { systemConfigDirs = savedDirs }()
+
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(root, "xdg"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "home"))
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv(EnvConfig, filepath.Join(root, "missing.json"))
+		if _, err := DiscoverLayers(""); err == nil {
+			t.Error("Expected error for missing $KEYLENGTH_CONFIG file")
+		}
+	})
+
+	t.Run("Flag", func(t *testing.T) {
+		t.Setenv(EnvConfig, "")
+		if _, err := DiscoverLayers(filepath.Join(root, "missing.json")); err == nil {
+			t.Error("Expected error for missing --config file")
+		}
+	})
+
+	t.Run("EmbeddedOnly", func(t *testing.T) {
+		t.Setenv(EnvConfig, "")
+		layers, err := DiscoverLayers("")
+		if err != nil {
+			t.Fatalf("DiscoverLayers() error: %v", err)
+		}
+		if len(layers) != 1 || layers[0].Name != "embedded" {
+			t.Errorf("Expected only the embedded layer, got %+v", layers)
+		}
+	})
+}
+
+func TestNewLayeredConfigWarnings(t *testing.T) {
+	path := writeStandardsFile(t, t.TempDir(), `{"standards": {"Legacy": {"RSA": 2048, "ECC": 256, "cut_off_year": 2020}}}`)
+	layer, err := FileLayer(path)
+	if err != nil {
+		t.Fatalf("FileLayer() error: %v", err)
+	}
+
+	cfg, err := NewLayeredConfig([]Layer{layer}, "Legacy")
+	if err != nil {
+		t.Fatalf("NewLayeredConfig() error: %v", err)
+	}
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "cut-off year 2020 is in the past") {
+		t.Errorf("Expected a cut-off year warning, got %v", warnings)
+	}
+
+	cfg.Year = 2020
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warning in the cut-off year, got %v", warnings)
+	}
+}
+
+func TestNewLayeredConfigWarningsEmbedded(t *testing.T) {
+	cfg, err := NewLayeredConfig([]Layer{EmbeddedLayer()}, "NIST")
+	if err != nil {
+		t.Fatalf("NewLayeredConfig() error: %v", err)
+	}
+	// Every bundled cut-off year has passed by then.
+	cfg.Year = 2099
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected the embedded layer to stay silent, got %v", warnings)
+	}
+}
@@ -0,0 +1,256 @@
+package ecc
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/types"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+type namedCurve struct {
+	name    string
+	bitSize int
+}
+
+var oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// namedCurves covers the curves seen in certificates in the wild, including
+// ones crypto/x509 refuses to parse.
+var namedCurves = map[string]namedCurve{
+	"1.2.840.10045.3.1.1":     {"P-192", 192},
+	"1.3.132.0.33":            {"P-224", 224},
+	"1.2.840.10045.3.1.7":     {"P-256", 256},
+	"1.3.132.0.34":            {"P-384", 384},
+	"1.3.132.0.35":            {"P-521", 521},
+	"1.3.132.0.8":             {"secp160r1", 160},
+	"1.3.132.0.9":             {"secp160k1", 160},
+	"1.3.132.0.30":            {"secp160r2", 160},
+	"1.3.132.0.31":            {"secp192k1", 192},
+	"1.3.132.0.32":            {"secp224k1", 224},
+	"1.3.132.0.10":            {"secp256k1", 256},
+	"1.3.132.0.1":             {"sect163k1", 163},
+	"1.3.132.0.15":            {"sect163r2", 163},
+	"1.3.132.0.26":            {"sect233k1", 233},
+	"1.3.132.0.27":            {"sect233r1", 233},
+	"1.3.132.0.16":            {"sect283k1", 283},
+	"1.3.132.0.17":            {"sect283r1", 283},
+	"1.3.132.0.36":            {"sect409k1", 409},
+	"1.3.132.0.37":            {"sect409r1", 409},
+	"1.3.132.0.38":            {"sect571k1", 571},
+	"1.3.132.0.39":            {"sect571r1", 571},
+	"1.3.36.3.3.2.8.1.1.1":    {"brainpoolP160r1", 160},
+	"1.3.36.3.3.2.8.1.1.3":    {"brainpoolP192r1", 192},
+	"1.3.36.3.3.2.8.1.1.5":    {"brainpoolP224r1", 224},
+	"1.3.36.3.3.2.8.1.1.7":    {"brainpoolP256r1", 256},
+	"1.3.36.3.3.2.8.1.1.9":    {"brainpoolP320r1", 320},
+	"1.3.36.3.3.2.8.1.1.11":   {"brainpoolP384r1", 384},
+	"1.3.36.3.3.2.8.1.1.13":   {"brainpoolP512r1", 512},
+	"1.2.250.1.223.101.256.1": {"FRP256v1", 256},
+	"1.2.156.10197.1.301":     {"SM2", 256},
+}
+
+var curveAliases = map[string]string{
+	"secp192r1":  "P-192",
+	"prime192v1": "P-192",
+	"secp224r1":  "P-224",
+	"secp256r1":  "P-256",
+	"prime256v1": "P-256",
+	"secp384r1":  "P-384",
+	"secp521r1":  "P-521",
+}
+
+// CanonicalCurveName maps the OpenSSL and SEC 2 names of the NIST curves to
+// the names used by crypto/elliptic, so policy files may use either.
+func CanonicalCurveName(name string) string {
+	for alias, canonical := range curveAliases {
+		if strings.EqualFold(name, alias) {
+			return canonical
+		}
+	}
+	return name
+}
+
+// ecSPKI is an EC SubjectPublicKeyInfo decoded without crypto/x509, so keys
+// on curves it does not implement, with explicit parameters or with invalid
+// points can still be identified and checked.
+type ecSPKI struct {
+	curve namedCurve
+	// params is nil when points on the curve cannot be validated.
+	params   *curveParams
+	explicit bool
+	// spoofed names the curve whose group explicit parameters reuse with a
+	// different generator.
+	spoofed string
+	point   []byte
+}
+
+// parseECSPKI reads the curve and public point of an EC SubjectPublicKeyInfo
+// without validating the point.
+func parseECSPKI(spki []byte) (*ecSPKI, error) {
+	input := cryptobyte.String(spki)
+	var info, algorithm cryptobyte.String
+	var algorithmOID asn1.ObjectIdentifier
+	if !input.ReadASN1(&info, cryptobyte_asn1.SEQUENCE) ||
+		!info.ReadASN1(&algorithm, cryptobyte_asn1.SEQUENCE) ||
+		!algorithm.ReadASN1ObjectIdentifier(&algorithmOID) {
+		return nil, errors.New("malformed subject public key info")
+	}
+	if !algorithmOID.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("public key is not an EC key")
+	}
+
+	key := &ecSPKI{}
+	switch {
+	case algorithm.PeekASN1Tag(cryptobyte_asn1.OBJECT_IDENTIFIER):
+		var curveOID asn1.ObjectIdentifier
+		if !algorithm.ReadASN1ObjectIdentifier(&curveOID) {
+			return nil, errors.New("malformed EC curve identifier")
+		}
+		curve, ok := namedCurves[curveOID.String()]
+		if !ok {
+			return nil, errors.New("unknown elliptic curve: " + curveOID.String())
+		}
+		key.curve = curve
+		key.params = paramsFor(curve.name)
+	case algorithm.PeekASN1Tag(cryptobyte_asn1.SEQUENCE):
+		params, err := parseExplicitParameters(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		key.explicit = true
+		key.params = params
+		key.curve = namedCurve{name: "explicit", bitSize: params.p.BitLen()}
+		if name, ok := matchNamedCurve(params); ok {
+			key.curve.name = name
+		} else if name, ok := spoofedCurve(params); ok {
+			key.spoofed = name
+		}
+	default:
+		return nil, errors.New("EC key does not specify a curve")
+	}
+
+	var point asn1.BitString
+	if !info.ReadASN1BitString(&point) || point.BitLength%8 != 0 {
+		return nil, errors.New("malformed EC public point")
+	}
+	key.point = point.Bytes
+	return key, nil
+}
+
+func (k *ecSPKI) explicitFinding() types.Finding {
+	switch {
+	case k.curve.name != "explicit":
+		return types.Finding{
+			Check:    "ecc-explicit-parameters",
+			Severity: types.SeverityWarning,
+			Message:  fmt.Sprintf("Key spells out the parameters of %s instead of naming the curve (RFC 5480)", k.curve.name),
+		}
+	case k.spoofed != "":
+		return types.Finding{
+			Check:    "ecc-explicit-parameters",
+			Severity: types.SeverityInvalid,
+			Message:  fmt.Sprintf("Explicit parameters reuse the %s group with a different generator (CVE-2020-0601)", k.spoofed),
+		}
+	}
+	return types.Finding{
+		Check:    "ecc-explicit-parameters",
+		Severity: types.SeverityInvalid,
+		Message:  "Explicit curve parameters do not match any named curve",
+	}
+}
+
+// parseCertificateKey decodes the EC key of a DER certificate and returns the
+// key's SubjectPublicKeyInfo along with it.
+func parseCertificateKey(der []byte) (*ecSPKI, []byte, error) {
+	spki, err := certificateSPKI(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parseECSPKI(spki)
+	return key, spki, err
+}
+
+// signatureAlgorithms maps certificate signature OIDs to the algorithms
+// crypto/x509 names, for certificates it cannot parse. RSASSA-PSS is
+// resolved from its parameters in pssAlgorithms.
+var signatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"1.2.840.113549.1.1.2":   x509.MD2WithRSA,
+	"1.2.840.113549.1.1.4":   x509.MD5WithRSA,
+	"1.2.840.113549.1.1.5":   x509.SHA1WithRSA,
+	"1.2.840.113549.1.1.11":  x509.SHA256WithRSA,
+	"1.2.840.113549.1.1.12":  x509.SHA384WithRSA,
+	"1.2.840.113549.1.1.13":  x509.SHA512WithRSA,
+	"1.2.840.10040.4.3":      x509.DSAWithSHA1,
+	"2.16.840.1.101.3.4.3.2": x509.DSAWithSHA256,
+	"1.2.840.10045.4.1":      x509.ECDSAWithSHA1,
+	"1.2.840.10045.4.3.2":    x509.ECDSAWithSHA256,
+	"1.2.840.10045.4.3.3":    x509.ECDSAWithSHA384,
+	"1.2.840.10045.4.3.4":    x509.ECDSAWithSHA512,
+	"1.3.101.112":            x509.PureEd25519,
+}
+
+const oidSignatureRSAPSS = "1.2.840.113549.1.1.10"
+
+// pssAlgorithms maps the hash OID in RSASSA-PSS parameters to the algorithm.
+var pssAlgorithms = map[string]x509.SignatureAlgorithm{
+	"2.16.840.1.101.3.4.2.1": x509.SHA256WithRSAPSS,
+	"2.16.840.1.101.3.4.2.2": x509.SHA384WithRSAPSS,
+	"2.16.840.1.101.3.4.2.3": x509.SHA512WithRSAPSS,
+}
+
+// certificateSignatureAlgorithm returns the signature algorithm of a DER
+// certificate without validating the rest of it. Algorithms crypto/x509 does
+// not know, including RSASSA-PSS over SHA-1, are UnknownSignatureAlgorithm.
+func certificateSignatureAlgorithm(der []byte) x509.SignatureAlgorithm {
+	input := cryptobyte.String(der)
+	var cert, algorithm cryptobyte.String
+	var oid asn1.ObjectIdentifier
+	if !input.ReadASN1(&cert, cryptobyte_asn1.SEQUENCE) ||
+		!cert.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!cert.ReadASN1(&algorithm, cryptobyte_asn1.SEQUENCE) ||
+		!algorithm.ReadASN1ObjectIdentifier(&oid) {
+		return x509.UnknownSignatureAlgorithm
+	}
+	if oid.String() != oidSignatureRSAPSS {
+		return signatureAlgorithms[oid.String()]
+	}
+
+	// RSASSA-PSS-params: the hash is an explicitly tagged AlgorithmIdentifier
+	// that defaults to SHA-1.
+	var params, hash, hashAlgorithm cryptobyte.String
+	var hashOID asn1.ObjectIdentifier
+	if !algorithm.ReadASN1(&params, cryptobyte_asn1.SEQUENCE) ||
+		!params.ReadASN1(&hash, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) ||
+		!hash.ReadASN1(&hashAlgorithm, cryptobyte_asn1.SEQUENCE) ||
+		!hashAlgorithm.ReadASN1ObjectIdentifier(&hashOID) {
+		return x509.UnknownSignatureAlgorithm
+	}
+	return pssAlgorithms[hashOID.String()]
+}
+
+// certificateSPKI returns the SubjectPublicKeyInfo of a DER certificate
+// without validating the rest of it.
+func certificateSPKI(der []byte) ([]byte, error) {
+	input := cryptobyte.String(der)
+	var cert, tbs cryptobyte.String
+	if !input.ReadASN1(&cert, cryptobyte_asn1.SEQUENCE) ||
+		!cert.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipOptionalASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) ||
+		!tbs.SkipASN1(cryptobyte_asn1.INTEGER) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!tbs.SkipASN1(cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("malformed certificate")
+	}
+	var spki cryptobyte.String
+	if !tbs.ReadASN1Element(&spki, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("malformed certificate public key")
+	}
+	return spki, nil
+}
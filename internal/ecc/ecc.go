@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 
 	"github.com/Horiodino/key-length/internal/types"
 )
@@ -14,6 +15,15 @@ type ECCKey struct {
 	data     []byte
 	cert     *x509.Certificate
 	ecdsaPub *ecdsa.PublicKey
+	// raw is set instead of cert or ecdsaPub when crypto/x509 cannot parse
+	// the key: on curves such as secp256k1, with explicit parameters, or with
+	// an invalid point. spki then holds the raw SubjectPublicKeyInfo.
+	raw  *ecSPKI
+	spki []byte
+	// rawCertificate is set when raw was read from a certificate, signed
+	// with rawSignature.
+	rawCertificate bool
+	rawSignature   x509.SignatureAlgorithm
 }
 
 func NewECCKey(data []byte) (*ECCKey, error) {
@@ -29,6 +39,11 @@ func NewECCKey(data []byte) (*ECCKey, error) {
 		case "EC PUBLIC KEY":
 			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
 			if err != nil {
+				if raw, rawErr := parseECSPKI(block.Bytes); rawErr == nil {
+					e.raw = raw
+					e.spki = block.Bytes
+					return e, nil
+				}
 				return nil, errors.New("failed to parse PEM EC public key: " + err.Error())
 			}
 			if ecdsaPub, ok := pub.(*ecdsa.PublicKey); ok {
@@ -39,6 +54,13 @@ func NewECCKey(data []byte) (*ECCKey, error) {
 		case "CERTIFICATE":
 			cert, err := x509.ParseCertificate(block.Bytes)
 			if err != nil {
+				if raw, spki, rawErr := parseCertificateKey(block.Bytes); rawErr == nil {
+					e.raw = raw
+					e.spki = spki
+					e.rawCertificate = true
+					e.rawSignature = certificateSignatureAlgorithm(block.Bytes)
+					return e, nil
+				}
 				return nil, errors.New("failed to parse PEM certificate: " + err.Error())
 			}
 			if cert.PublicKeyAlgorithm == x509.ECDSA {
@@ -56,20 +78,42 @@ func NewECCKey(data []byte) (*ECCKey, error) {
 		e.cert = cert
 		return e, nil
 	}
+	if err != nil {
+		if raw, spki, rawErr := parseCertificateKey(data); rawErr == nil {
+			e.raw = raw
+			e.spki = spki
+			e.rawCertificate = true
+			e.rawSignature = certificateSignatureAlgorithm(data)
+			return e, nil
+		}
+	}
 
 	return nil, errors.New("unsupported ECC key format: expected PEM or X.509 DER")
 }
 
-func (e *ECCKey) GetLength() int {
-	var curve elliptic.Curve
+func (e *ECCKey) publicKey() *ecdsa.PublicKey {
 	if e.cert != nil {
 		if ecdsaPub, ok := e.cert.PublicKey.(*ecdsa.PublicKey); ok {
-			curve = ecdsaPub.Curve
+			return ecdsaPub
 		}
-	} else if e.ecdsaPub != nil {
-		curve = e.ecdsaPub.Curve
+		return nil
 	}
+	return e.ecdsaPub
+}
 
+func (e *ECCKey) curve() elliptic.Curve {
+	if pub := e.publicKey(); pub != nil {
+		return pub.Curve
+	}
+	return nil
+}
+
+func (e *ECCKey) GetLength() int {
+	if e.raw != nil {
+		return e.raw.curve.bitSize
+	}
+
+	curve := e.curve()
 	if curve == nil {
 		return 0
 	}
@@ -77,9 +121,100 @@ func (e *ECCKey) GetLength() int {
 	return curve.Params().BitSize
 }
 
+// GetCurve returns the name of the key's curve, e.g. "P-256" or "secp256k1".
+func (e *ECCKey) GetCurve() string {
+	if e.raw != nil {
+		return e.raw.curve.name
+	}
+
+	curve := e.curve()
+	if curve == nil {
+		return ""
+	}
+
+	return curve.Params().Name
+}
+
+// Certificate returns the certificate the key was read from, if any.
+func (e *ECCKey) Certificate() *x509.Certificate {
+	return e.cert
+}
+
+// RawSignatureAlgorithm returns the signature algorithm of a certificate
+// that crypto/x509 could not parse, such as one on secp256k1. ok is false
+// unless the key was read from such a certificate.
+func (e *ECCKey) RawSignatureAlgorithm() (algorithm x509.SignatureAlgorithm, ok bool) {
+	return e.rawSignature, e.rawCertificate
+}
+
+// SPKI returns the DER-encoded SubjectPublicKeyInfo of the key.
+func (e *ECCKey) SPKI() ([]byte, error) {
+	switch {
+	case e.raw != nil:
+		return e.spki, nil
+	case e.cert != nil:
+		return e.cert.RawSubjectPublicKeyInfo, nil
+	case e.ecdsaPub != nil:
+		return x509.MarshalPKIXPublicKey(e.ecdsaPub)
+	}
+	return nil, errors.New("key has no public key")
+}
+
+// IsSecure requires the curve size to meet the threshold and the key to pass
+// the checks in Check; warnings alone do not make a key insecure.
 func (e *ECCKey) IsSecure(threshold int) bool {
 	length := e.GetLength()
-	return length >= threshold
+	if length < threshold {
+		return false
+	}
+	for _, finding := range e.Check() {
+		if finding.Severity != types.SeverityWarning {
+			return false
+		}
+	}
+	return true
+}
+
+// Check validates the public point and flags explicit curve parameters. A
+// point that fails validation makes the key invalid rather than weak.
+func (e *ECCKey) Check() []types.Finding {
+	var findings []types.Finding
+	if e.raw != nil && e.raw.explicit {
+		findings = append(findings, e.raw.explicitFinding())
+	}
+	if err := e.validatePoint(); err != nil {
+		findings = append(findings, types.Finding{
+			Check:    "ecc-point",
+			Severity: types.SeverityInvalid,
+			Message:  fmt.Sprintf("Invalid public key on %s: %v", e.GetCurve(), err),
+		})
+	}
+	return findings
+}
+
+// validatePoint returns nil when the curve's parameters are unknown, since
+// such points cannot be checked.
+func (e *ECCKey) validatePoint() error {
+	if e.raw != nil {
+		if e.raw.params == nil {
+			return nil
+		}
+		x, y, err := e.raw.params.decodePoint(e.raw.point)
+		if err != nil {
+			return err
+		}
+		return e.raw.params.validatePoint(x, y)
+	}
+
+	pub := e.publicKey()
+	if pub == nil {
+		return nil
+	}
+	params := paramsFor(pub.Curve.Params().Name)
+	if params == nil {
+		return nil
+	}
+	return params.validatePoint(pub.X, pub.Y)
 }
 
 func (e *ECCKey) AdjustForYear(year int) int {
@@ -97,4 +232,11 @@ func (e *ECCKey) GetAlgorithm() string {
 	return "ECC"
 }
 
-var _ types.KeyLengthEvaluator = (*ECCKey)(nil)
+var (
+	_ types.KeyLengthEvaluator   = (*ECCKey)(nil)
+	_ types.CurveEvaluator       = (*ECCKey)(nil)
+	_ types.SPKIEncoder          = (*ECCKey)(nil)
+	_ types.CertificateHolder    = (*ECCKey)(nil)
+	_ types.RawCertificateHolder = (*ECCKey)(nil)
+	_ types.SanityChecker        = (*ECCKey)(nil)
+)
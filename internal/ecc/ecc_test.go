@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"math/big"
 	"strconv"
@@ -211,6 +212,197 @@ func TestAdjustForYear(t *testing.T) {
 	}
 }
 
+func TestGetCurve(t *testing.T) {
+	t.Run("P256Certificate", func(t *testing.T) {
+		key, err := NewECCKey(generateTestCertificate(t, elliptic.P256(), true))
+		if err != nil {
+			t.Fatalf("Failed to create ECCKey: %v", err)
+		}
+		if curve := key.GetCurve(); curve != "P-256" {
+			t.Errorf("Expected curve P-256, got %q", curve)
+		}
+	})
+
+	t.Run("P384PublicKey", func(t *testing.T) {
+		key, err := NewECCKey(generateECPEMPublicKey(t, elliptic.P384()))
+		if err != nil {
+			t.Fatalf("Failed to create ECCKey: %v", err)
+		}
+		if curve := key.GetCurve(); curve != "P-384" {
+			t.Errorf("Expected curve P-384, got %q", curve)
+		}
+	})
+
+	unsupported := []struct {
+		name    string
+		oid     asn1.ObjectIdentifier
+		curve   string
+		bitSize int
+	}{
+		{"Secp256k1", asn1.ObjectIdentifier{1, 3, 132, 0, 10}, "secp256k1", 256},
+		{"BrainpoolP384r1", asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11}, "brainpoolP384r1", 384},
+	}
+	for _, tc := range unsupported {
+		t.Run(tc.name+"CertificateDER", func(t *testing.T) {
+			key, err := NewECCKey(generateNamedCurveCertificate(t, tc.oid))
+			if err != nil {
+				t.Fatalf("Failed to create ECCKey: %v", err)
+			}
+			if curve := key.GetCurve(); curve != tc.curve {
+				t.Errorf("Expected curve %s, got %q", tc.curve, curve)
+			}
+			if length := key.GetLength(); length != tc.bitSize {
+				t.Errorf("Expected length %d, got %d", tc.bitSize, length)
+			}
+		})
+
+		t.Run(tc.name+"PublicKeyPEM", func(t *testing.T) {
+			pemKey := pem.EncodeToMemory(&pem.Block{Type: "EC PUBLIC KEY", Bytes: generateNamedCurveSPKI(t, tc.oid)})
+			key, err := NewECCKey(pemKey)
+			if err != nil {
+				t.Fatalf("Failed to create ECCKey: %v", err)
+			}
+			if curve := key.GetCurve(); curve != tc.curve {
+				t.Errorf("Expected curve %s, got %q", tc.curve, curve)
+			}
+		})
+	}
+
+	t.Run("UnknownCurve", func(t *testing.T) {
+		_, err := NewECCKey(generateNamedCurveCertificate(t, asn1.ObjectIdentifier{1, 2, 3, 4}))
+		if err == nil {
+			t.Errorf("Expected error for unknown curve")
+		}
+	})
+}
+
+func TestRawSignatureAlgorithm(t *testing.T) {
+	secp256k1 := asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	pssParams, err := asn1.Marshal(struct {
+		Hash pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	}{pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}}})
+	if err != nil {
+		t.Fatalf("Failed to marshal PSS parameters: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		sigAlg pkix.AlgorithmIdentifier
+		want   x509.SignatureAlgorithm
+	}{
+		{"ECDSAWithSHA256", pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}}, x509.ECDSAWithSHA256},
+		{"SHA1WithRSA", pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}}, x509.SHA1WithRSA},
+		{"RSAPSSWithSHA384", pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}, Parameters: asn1.RawValue{FullBytes: pssParams}}, x509.SHA384WithRSAPSS},
+		{"Unknown", pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}}, x509.UnknownSignatureAlgorithm},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := NewECCKey(generateSignedNamedCurveCertificate(t, secp256k1, tc.sigAlg))
+			if err != nil {
+				t.Fatalf("Failed to create ECCKey: %v", err)
+			}
+			algorithm, ok := key.RawSignatureAlgorithm()
+			if !ok || algorithm != tc.want {
+				t.Errorf("RawSignatureAlgorithm() = %v, %v, want %v, true", algorithm, ok, tc.want)
+			}
+		})
+	}
+
+	t.Run("PublicKey", func(t *testing.T) {
+		pemKey := pem.EncodeToMemory(&pem.Block{Type: "EC PUBLIC KEY", Bytes: generateNamedCurveSPKI(t, secp256k1)})
+		key, err := NewECCKey(pemKey)
+		if err != nil {
+			t.Fatalf("Failed to create ECCKey: %v", err)
+		}
+		if _, ok := key.RawSignatureAlgorithm(); ok {
+			t.Error("Expected no raw signature algorithm for a bare public key")
+		}
+	})
+}
+
+func TestCanonicalCurveName(t *testing.T) {
+	testCases := map[string]string{
+		"prime256v1": "P-256",
+		"secp384r1":  "P-384",
+		"SECP521R1":  "P-521",
+		"secp256k1":  "secp256k1",
+		"P-256":      "P-256",
+	}
+	for name, expected := range testCases {
+		if got := CanonicalCurveName(name); got != expected {
+			t.Errorf("CanonicalCurveName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+// generateNamedCurveSPKI builds an EC SubjectPublicKeyInfo on a curve that
+// crypto/ecdsa cannot generate keys for. The point is random bytes.
+func generateNamedCurveSPKI(t *testing.T, curveOID asn1.ObjectIdentifier) []byte {
+	params, err := asn1.Marshal(curveOID)
+	if err != nil {
+		t.Fatalf("Failed to marshal curve OID: %v", err)
+	}
+	point := make([]byte, 65)
+	point[0] = 4
+	if _, err := rand.Read(point[1:]); err != nil {
+		t.Fatalf("Failed to generate point: %v", err)
+	}
+	spki, err := asn1.Marshal(testSPKI{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal SPKI: %v", err)
+	}
+	return spki
+}
+
+type testSPKI struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func generateNamedCurveCertificate(t *testing.T, curveOID asn1.ObjectIdentifier) []byte {
+	return generateSignedNamedCurveCertificate(t, curveOID, pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}})
+}
+
+func generateSignedNamedCurveCertificate(t *testing.T, curveOID asn1.ObjectIdentifier, sigAlg pkix.AlgorithmIdentifier) []byte {
+	var spki testSPKI
+	if _, err := asn1.Unmarshal(generateNamedCurveSPKI(t, curveOID), &spki); err != nil {
+		t.Fatalf("Failed to unmarshal SPKI: %v", err)
+	}
+	name := pkix.Name{CommonName: "Test Named Curve Cert"}.ToRDNSequence()
+	cert := struct {
+		TBS struct {
+			Version      int `asn1:"optional,explicit,default:0,tag:0"`
+			SerialNumber *big.Int
+			Signature    pkix.AlgorithmIdentifier
+			Issuer       pkix.RDNSequence
+			Validity     struct{ NotBefore, NotAfter time.Time }
+			Subject      pkix.RDNSequence
+			PublicKey    testSPKI
+		}
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{}
+	cert.TBS.Version = 2
+	cert.TBS.SerialNumber = big.NewInt(1)
+	cert.TBS.Signature = sigAlg
+	cert.TBS.Issuer = name
+	cert.TBS.Validity.NotBefore = time.Now().UTC().Truncate(time.Second)
+	cert.TBS.Validity.NotAfter = cert.TBS.Validity.NotBefore.Add(365 * 24 * time.Hour)
+	cert.TBS.Subject = name
+	cert.TBS.PublicKey = spki
+	cert.SignatureAlgorithm = sigAlg
+	cert.Signature = asn1.BitString{Bytes: []byte{0x30, 0x00}, BitLength: 16}
+
+	der, err := asn1.Marshal(cert)
+	if err != nil {
+		t.Fatalf("Failed to marshal certificate: %v", err)
+	}
+	return der
+}
+
 func generateTestCertificate(t *testing.T, curve elliptic.Curve, asPEM bool) []byte {
 	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
 	if err != nil {
@@ -0,0 +1,300 @@
+package ecc
+
+import (
+	"crypto/elliptic"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// curveParams describes a short Weierstrass curve y² = x³ + ax + b over the
+// prime field p with base point (gx, gy) of order n and cofactor h.
+type curveParams struct {
+	p, a, b *big.Int
+	gx, gy  *big.Int
+	n, h    *big.Int
+}
+
+var (
+	errPointAtInfinity = errors.New("public point is the point at infinity")
+	errPointNotOnCurve = errors.New("public point is not on the curve")
+	errPointOrder      = errors.New("public point is not in the prime-order subgroup")
+	errPointEncoding   = errors.New("public point encoding is malformed")
+)
+
+var (
+	oidPrimeField          = asn1.ObjectIdentifier{1, 2, 840, 10045, 1, 1}
+	oidCharacteristicTwoEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 1, 2}
+)
+
+var (
+	curveParametersOnce sync.Once
+	curveParameters     map[string]*curveParams
+)
+
+// loadCurveParameters builds the parameters of the prime curves whose points
+// can be validated. The NIST curves come from crypto/elliptic; the others are
+// taken from SEC 2 and RFC 5639.
+func loadCurveParameters() {
+	curveParametersOnce.Do(func() {
+		curveParameters = map[string]*curveParams{
+			"P-192": hexParams(
+				"fffffffffffffffffffffffffffffffeffffffffffffffff",
+				"fffffffffffffffffffffffffffffffefffffffffffffffc",
+				"64210519e59c80e70fa7e9ab72243049feb8deecc146b9b1",
+				"188da80eb03090f67cbf20eb43a18800f4ff0afd82ff1012",
+				"07192b95ffc8da78631011ed6b24cdd573f977a11e794811",
+				"ffffffffffffffffffffffff99def836146bc9b1b4d22831"),
+			"secp256k1": hexParams(
+				"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+				"0",
+				"7",
+				"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+				"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8",
+				"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+			"brainpoolP256r1": hexParams(
+				"a9fb57dba1eea9bc3e660a909d838d726e3bf623d52620282013481d1f6e5377",
+				"7d5a0975fc2c3057eef67530417affe7fb8055c126dc5c6ce94a4b44f330b5d9",
+				"26dc5c6ce94a4b44f330b5d9bbd77cbf958416295cf7e1ce6bccdc18ff8c07b6",
+				"8bd2aeb9cb7e57cb2c4b482ffc81b7afb9de27e1e3bd23c23a4453bd9ace3262",
+				"547ef835c3dac4fd97f8461a14611dc9c27745132ded8e545c1d54c72f046997",
+				"a9fb57dba1eea9bc3e660a909d838d718c397aa3b561a6f7901e0e82974856a7"),
+			"brainpoolP384r1": hexParams(
+				"8cb91e82a3386d280f5d6f7e50e641df152f7109ed5456b412b1da197fb71123acd3a729901d1a71874700133107ec53",
+				"7bc382c63d8c150c3c72080ace05afa0c2bea28e4fb22787139165efba91f90f8aa5814a503ad4eb04a8c7dd22ce2826",
+				"04a8c7dd22ce28268b39b55416f0447c2fb77de107dcd2a62e880ea53eeb62d57cb4390295dbc9943ab78696fa504c11",
+				"1d1c64f068cf45ffa2a63a81b7c13f6b8847a3e77ef14fe3db7fcafe0cbd10e8e826e03436d646aaef87b2e247d4af1e",
+				"8abe1d7520f9c2a45cb1eb8e95cfd55262b70b29feec5864e19c054ff99129280e4646217791811142820341263c5315",
+				"8cb91e82a3386d280f5d6f7e50e641df152f7109ed5456b31f166e6cac0425a7cf3ab6af6b7fc3103b883202e9046565"),
+			"brainpoolP512r1": hexParams(
+				"aadd9db8dbe9c48b3fd4e6ae33c9fc07cb308db3b3c9d20ed6639cca703308717d4d9b009bc66842aecda12ae6a380e62881ff2f2d82c68528aa6056583a48f3",
+				"7830a3318b603b89e2327145ac234cc594cbdd8d3df91610a83441caea9863bc2ded5d5aa8253aa10a2ef1c98b9ac8b57f1117a72bf2c7b9e7c1ac4d77fc94ca",
+				"3df91610a83441caea9863bc2ded5d5aa8253aa10a2ef1c98b9ac8b57f1117a72bf2c7b9e7c1ac4d77fc94cadc083e67984050b75ebae5dd2809bd638016f723",
+				"81aee4bdd82ed9645a21322e9c4c6a9385ed9f70b5d916c1b43b62eef4d0098eff3b1f78e2d0d48d50d1687b93b97d5f7c6d5047406a5e688b352209bcb9f822",
+				"7dde385d566332ecc0eabfa9cf7822fdf209f70024a57b1aa000c55b881f8111b2dcde494a5f485e5bca4bd88a2763aed1ca2b2fa8f0540678cd1e0f3ad80892",
+				"aadd9db8dbe9c48b3fd4e6ae33c9fc07cb308db3b3c9d20ed6639cca70330870553e5c414ca92619418661197fac10471db1d381085ddaddb58796829ca90069"),
+		}
+		for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+			params := curve.Params()
+			curveParameters[params.Name] = &curveParams{
+				p:  params.P,
+				a:  new(big.Int).Sub(params.P, big.NewInt(3)),
+				b:  params.B,
+				gx: params.Gx,
+				gy: params.Gy,
+				n:  params.N,
+				h:  big.NewInt(1),
+			}
+		}
+	})
+}
+
+func hexParams(p, a, b, gx, gy, n string) *curveParams {
+	parse := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 16)
+		return v
+	}
+	return &curveParams{p: parse(p), a: parse(a), b: parse(b), gx: parse(gx), gy: parse(gy), n: parse(n), h: big.NewInt(1)}
+}
+
+// paramsFor returns the parameters of a named curve, or nil if its points
+// cannot be validated, as for the binary sect curves.
+func paramsFor(name string) *curveParams {
+	loadCurveParameters()
+	return curveParameters[name]
+}
+
+// equal reports whether both curves are the same group with the same base
+// point. A changed generator is how CVE-2020-0601 spoofed named curves.
+func (c *curveParams) equal(other *curveParams) bool {
+	return c.p.Cmp(other.p) == 0 && c.a.Cmp(other.a) == 0 && c.b.Cmp(other.b) == 0 &&
+		c.gx.Cmp(other.gx) == 0 && c.gy.Cmp(other.gy) == 0 && c.n.Cmp(other.n) == 0
+}
+
+// sameGroup reports whether only the generator differs.
+func (c *curveParams) sameGroup(other *curveParams) bool {
+	return c.p.Cmp(other.p) == 0 && c.a.Cmp(other.a) == 0 && c.b.Cmp(other.b) == 0 && c.n.Cmp(other.n) == 0
+}
+
+// matchNamedCurve finds the named curve with exactly the given parameters.
+func matchNamedCurve(params *curveParams) (string, bool) {
+	loadCurveParameters()
+	for name, known := range curveParameters {
+		if params.equal(known) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// spoofedCurve returns the named curve whose group the parameters reuse with
+// a different generator.
+func spoofedCurve(params *curveParams) (string, bool) {
+	loadCurveParameters()
+	for name, known := range curveParameters {
+		if params.sameGroup(known) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (c *curveParams) isOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(c.p) >= 0 || y.Sign() < 0 || y.Cmp(c.p) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, c.p)
+	return lhs.Cmp(c.rhs(x)) == 0
+}
+
+// rhs returns x³ + ax + b mod p.
+func (c *curveParams) rhs(x *big.Int) *big.Int {
+	r := new(big.Int).Mul(x, x)
+	r.Add(r, c.a)
+	r.Mul(r, x)
+	r.Add(r, c.b)
+	return r.Mod(r, c.p)
+}
+
+// add returns P + Q in affine coordinates, using nil for the point at
+// infinity. Speed does not matter for validating a single key.
+func (c *curveParams) add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+
+	var lambda *big.Int
+	if x1.Cmp(x2) == 0 {
+		sum := new(big.Int).Add(y1, y2)
+		if sum.Mod(sum, c.p).Sign() == 0 {
+			return nil, nil
+		}
+		num := new(big.Int).Mul(x1, x1)
+		num.Mul(num, big.NewInt(3))
+		num.Add(num, c.a)
+		den := new(big.Int).Lsh(y1, 1)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, c.p), c.p))
+	} else {
+		num := new(big.Int).Sub(y2, y1)
+		den := new(big.Int).Sub(x2, x1)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, c.p), c.p))
+	}
+	lambda.Mod(lambda, c.p)
+
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1).Sub(x3, x2).Mod(x3, c.p)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda).Sub(y3, y1).Mod(y3, c.p)
+	return x3, y3
+}
+
+func (c *curveParams) scalarMult(x, y, k *big.Int) (*big.Int, *big.Int) {
+	var rx, ry *big.Int
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		rx, ry = c.add(rx, ry, rx, ry)
+		if k.Bit(i) == 1 {
+			rx, ry = c.add(rx, ry, x, y)
+		}
+	}
+	return rx, ry
+}
+
+// decodePoint reads an uncompressed or compressed SEC 1 point.
+func (c *curveParams) decodePoint(data []byte) (*big.Int, *big.Int, error) {
+	size := (c.p.BitLen() + 7) / 8
+	switch {
+	case len(data) == 1 && data[0] == 0:
+		return nil, nil, errPointAtInfinity
+	case len(data) == 1+2*size && data[0] == 4:
+		return new(big.Int).SetBytes(data[1 : 1+size]), new(big.Int).SetBytes(data[1+size:]), nil
+	case len(data) == 1+size && (data[0] == 2 || data[0] == 3):
+		x := new(big.Int).SetBytes(data[1:])
+		if x.Cmp(c.p) >= 0 {
+			return nil, nil, errPointNotOnCurve
+		}
+		y := new(big.Int).ModSqrt(c.rhs(x), c.p)
+		if y == nil {
+			return nil, nil, errPointNotOnCurve
+		}
+		if y.Bit(0) != uint(data[0]&1) {
+			y.Sub(c.p, y)
+		}
+		return x, y, nil
+	}
+	return nil, nil, errPointEncoding
+}
+
+// validatePoint applies the public key checks of NIST SP 800-56A 5.6.2.3.3:
+// the point is not the identity, lies on the curve and, when the cofactor is
+// not 1, has the order of the base point.
+func (c *curveParams) validatePoint(x, y *big.Int) error {
+	if x == nil || y == nil {
+		return errPointAtInfinity
+	}
+	if !c.isOnCurve(x, y) {
+		return errPointNotOnCurve
+	}
+	if c.h == nil || c.h.Cmp(big.NewInt(1)) != 0 {
+		if rx, _ := c.scalarMult(x, y, c.n); rx != nil {
+			return errPointOrder
+		}
+	}
+	return nil
+}
+
+// parseExplicitParameters reads SEC 1 SpecifiedECDomain parameters. Only
+// prime fields are supported.
+func parseExplicitParameters(der cryptobyte.String) (*curveParams, error) {
+	var domain, fieldID, curve cryptobyte.String
+	var version int64
+	var fieldType asn1.ObjectIdentifier
+	if !der.ReadASN1(&domain, cryptobyte_asn1.SEQUENCE) ||
+		!domain.ReadASN1Integer(&version) ||
+		!domain.ReadASN1(&fieldID, cryptobyte_asn1.SEQUENCE) ||
+		!fieldID.ReadASN1ObjectIdentifier(&fieldType) {
+		return nil, errors.New("malformed explicit curve parameters")
+	}
+	if fieldType.Equal(oidCharacteristicTwoEC) {
+		return nil, errors.New("explicit binary field curve parameters are not supported")
+	}
+	if !fieldType.Equal(oidPrimeField) {
+		return nil, errors.New("unknown field type in explicit curve parameters: " + fieldType.String())
+	}
+
+	params := &curveParams{p: new(big.Int), n: new(big.Int)}
+	var a, b, base []byte
+	if !fieldID.ReadASN1Integer(params.p) ||
+		!domain.ReadASN1(&curve, cryptobyte_asn1.SEQUENCE) ||
+		!curve.ReadASN1Bytes(&a, cryptobyte_asn1.OCTET_STRING) ||
+		!curve.ReadASN1Bytes(&b, cryptobyte_asn1.OCTET_STRING) ||
+		!domain.ReadASN1Bytes(&base, cryptobyte_asn1.OCTET_STRING) ||
+		!domain.ReadASN1Integer(params.n) {
+		return nil, errors.New("malformed explicit curve parameters")
+	}
+	if params.p.Cmp(big.NewInt(3)) <= 0 || !params.p.ProbablyPrime(20) || params.n.Sign() <= 0 {
+		return nil, errors.New("invalid explicit curve parameters")
+	}
+	params.a = new(big.Int).SetBytes(a)
+	params.b = new(big.Int).SetBytes(b)
+	if !domain.Empty() {
+		params.h = new(big.Int)
+		if !domain.ReadASN1Integer(params.h) {
+			return nil, errors.New("malformed explicit curve cofactor")
+		}
+	}
+
+	gx, gy, err := params.decodePoint(base)
+	if err != nil {
+		return nil, errors.New("invalid base point in explicit curve parameters")
+	}
+	params.gx, params.gy = gx, gy
+	return params, nil
+}
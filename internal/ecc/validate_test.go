@@ -0,0 +1,165 @@
+package ecc
+
+import (
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+func TestCurveParameters(t *testing.T) {
+	loadCurveParameters()
+	for name, params := range curveParameters {
+		t.Run(name, func(t *testing.T) {
+			if !params.isOnCurve(params.gx, params.gy) {
+				t.Fatal("Generator is not on the curve")
+			}
+			if x, _ := params.scalarMult(params.gx, params.gy, params.n); x != nil {
+				t.Error("Generator does not have order n")
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p256 := paramsFor("P-256")
+	secp256k1 := paramsFor("secp256k1")
+	oidP256 := asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidSecp256k1 := asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+	offCurve := encodePoint(p256, p256.gx, new(big.Int).Add(p256.gy, big.NewInt(1)))
+	compressed := append([]byte{byte(2 + secp256k1.gy.Bit(0))}, secp256k1.gx.FillBytes(make([]byte, 32))...)
+
+	spoofed := *p256
+	spoofed.gx, spoofed.gy = p256.scalarMult(p256.gx, p256.gy, big.NewInt(2))
+
+	wrongOrder := *p256
+	wrongOrder.n = new(big.Int).Sub(p256.n, big.NewInt(2))
+	wrongOrder.h = big.NewInt(4)
+
+	testCases := []struct {
+		name         string
+		key          []byte
+		wantCurve    string
+		wantFindings map[string]string
+	}{
+		{"GeneratedP256", generateTestCertificate(t, elliptic.P256(), true), "P-256", nil},
+		{"PointNotOnCurve", namedCurvePEM(t, oidP256, offCurve), "P-256",
+			map[string]string{"ecc-point": types.SeverityInvalid}},
+		{"PointAtInfinity", namedCurvePEM(t, oidP256, []byte{0}), "P-256",
+			map[string]string{"ecc-point": types.SeverityInvalid}},
+		{"MalformedPoint", namedCurvePEM(t, oidP256, []byte{4, 1, 2, 3}), "P-256",
+			map[string]string{"ecc-point": types.SeverityInvalid}},
+		{"Secp256k1Compressed", namedCurvePEM(t, oidSecp256k1, compressed), "secp256k1", nil},
+		{"ExplicitP256", explicitCurvePEM(t, p256, encodePoint(p256, p256.gx, p256.gy)), "P-256",
+			map[string]string{"ecc-explicit-parameters": types.SeverityWarning}},
+		{"SpoofedGenerator", explicitCurvePEM(t, &spoofed, encodePoint(p256, p256.gx, p256.gy)), "explicit",
+			map[string]string{"ecc-explicit-parameters": types.SeverityInvalid}},
+		{"WrongSubgroupOrder", explicitCurvePEM(t, &wrongOrder, encodePoint(p256, p256.gx, p256.gy)), "explicit",
+			map[string]string{"ecc-explicit-parameters": types.SeverityInvalid, "ecc-point": types.SeverityInvalid}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := NewECCKey(tc.key)
+			if err != nil {
+				t.Fatalf("Failed to create ECCKey: %v", err)
+			}
+			if curve := key.GetCurve(); curve != tc.wantCurve {
+				t.Errorf("Expected curve %s, got %q", tc.wantCurve, curve)
+			}
+
+			findings := key.Check()
+			if len(findings) != len(tc.wantFindings) {
+				t.Fatalf("Expected %d findings, got %+v", len(tc.wantFindings), findings)
+			}
+			for _, finding := range findings {
+				if severity, ok := tc.wantFindings[finding.Check]; !ok || severity != finding.Severity {
+					t.Errorf("Unexpected finding %+v", finding)
+				}
+			}
+			if _, invalid := tc.wantFindings["ecc-point"]; invalid && key.IsSecure(0) {
+				t.Error("Expected key with an invalid point to be insecure")
+			}
+		})
+	}
+
+	t.Run("ErrorMessages", func(t *testing.T) {
+		if _, _, err := p256.decodePoint([]byte{0}); err != errPointAtInfinity {
+			t.Errorf("Expected %v, got %v", errPointAtInfinity, err)
+		}
+		x, y, err := p256.decodePoint(offCurve)
+		if err != nil {
+			t.Fatalf("decodePoint() error: %v", err)
+		}
+		if err := p256.validatePoint(x, y); err != errPointNotOnCurve {
+			t.Errorf("Expected %v, got %v", errPointNotOnCurve, err)
+		}
+		if err := wrongOrder.validatePoint(p256.gx, p256.gy); err != errPointOrder {
+			t.Errorf("Expected %v, got %v", errPointOrder, err)
+		}
+	})
+}
+
+func encodePoint(params *curveParams, x, y *big.Int) []byte {
+	size := (params.p.BitLen() + 7) / 8
+	point := make([]byte, 1+2*size)
+	point[0] = 4
+	x.FillBytes(point[1 : 1+size])
+	y.FillBytes(point[1+size:])
+	return point
+}
+
+func namedCurvePEM(t *testing.T, curveOID asn1.ObjectIdentifier, point []byte) []byte {
+	params, err := asn1.Marshal(curveOID)
+	if err != nil {
+		t.Fatalf("Failed to marshal curve OID: %v", err)
+	}
+	return ecPublicKeyPEM(t, params, point)
+}
+
+// explicitCurvePEM encodes a key with SEC 1 SpecifiedECDomain parameters.
+func explicitCurvePEM(t *testing.T, curve *curveParams, point []byte) []byte {
+	size := (curve.p.BitLen() + 7) / 8
+	domain := struct {
+		Version int
+		FieldID struct {
+			FieldType asn1.ObjectIdentifier
+			Prime     *big.Int
+		}
+		Curve struct {
+			A, B []byte
+		}
+		Base     []byte
+		Order    *big.Int
+		Cofactor *big.Int
+	}{Version: 1}
+	domain.FieldID.FieldType = oidPrimeField
+	domain.FieldID.Prime = curve.p
+	domain.Curve.A = curve.a.FillBytes(make([]byte, size))
+	domain.Curve.B = curve.b.FillBytes(make([]byte, size))
+	domain.Base = encodePoint(curve, curve.gx, curve.gy)
+	domain.Order = curve.n
+	domain.Cofactor = curve.h
+
+	params, err := asn1.Marshal(domain)
+	if err != nil {
+		t.Fatalf("Failed to marshal explicit parameters: %v", err)
+	}
+	return ecPublicKeyPEM(t, params, point)
+}
+
+func ecPublicKeyPEM(t *testing.T, params, point []byte) []byte {
+	spki, err := asn1.Marshal(testSPKI{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal SPKI: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PUBLIC KEY", Bytes: spki})
+}
@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/parse"
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+// ChainLink is the evaluation of one certificate in a presented chain. Err is
+// set instead of Result when the certificate's key could not be parsed.
+type ChainLink struct {
+	Position string
+	Subject  string
+	Result   *EvaluationResult
+	Err      error
+}
+
+// ChainResult holds every link of a chain. Its Status is that of the weakest
+// link, which Weakest indexes.
+type ChainResult struct {
+	Links   []ChainLink
+	Weakest int
+	Status  string
+}
+
+// EvaluateChain evaluates every certificate in the order the server sent
+// them, leaf first.
+func EvaluateChain(certs []*x509.Certificate, cfg *config.Config, checkExpiry bool, opts Options) *ChainResult {
+	chain := &ChainResult{}
+	for i, cert := range certs {
+		link := ChainLink{Position: chainPosition(certs, i), Subject: subjectName(cert)}
+		parsedKey, err := parse.ParseData(cert.Raw)
+		if err != nil {
+			link.Err = err
+		} else {
+			var certData []byte
+			if checkExpiry {
+				certData = cert.Raw
+			}
+			link.Result = EvaluateKeyWithOptions(parsedKey.Key.(types.KeyLengthEvaluator), cfg, certData, opts)
+		}
+		chain.Links = append(chain.Links, link)
+	}
+
+	for i, link := range chain.Links {
+		if link.rank() > chain.Links[chain.Weakest].rank() {
+			chain.Weakest = i
+		}
+	}
+
+	verdict := "Secure"
+	if len(chain.Links) > 0 {
+		switch chain.Links[chain.Weakest].rank() {
+		case 2:
+			verdict = "Invalid Key"
+		case 1:
+			verdict = "Insecure"
+		}
+	}
+	chain.Status = fmt.Sprintf("%s (%s)", verdict, cfg.SelectedStandard)
+	return chain
+}
+
+// rank orders links from strongest to weakest. A certificate that cannot be
+// evaluated is treated as insecure.
+func (l ChainLink) rank() int {
+	switch {
+	case l.Result == nil:
+		return 1
+	case l.Result.invalid():
+		return 2
+	case !strings.HasPrefix(l.Result.Status, "Secure"):
+		return 1
+	}
+	return 0
+}
+
+func chainPosition(certs []*x509.Certificate, i int) string {
+	cert := certs[i]
+	switch {
+	case i == 0:
+		return "Leaf"
+	case i == len(certs)-1 && bytes.Equal(cert.RawSubject, cert.RawIssuer):
+		return "Root"
+	}
+	return fmt.Sprintf("Intermediate %d", i)
+}
+
+func subjectName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// Backend is what one resolved address of a host presented on a port.
+type Backend struct {
+	Address string
+	Chain   *ChainResult
+	Trust   string
+	Leaf    *x509.Certificate
+}
+
+// CompareBackends describes how backends serving the same name differ in
+// their leaf certificate, trust status or chain verdict. It returns nil when
+// they agree.
+func CompareBackends(backends []Backend) []string {
+	if len(backends) < 2 {
+		return nil
+	}
+
+	describe := func(value func(Backend) string) string {
+		var parts []string
+		differ := false
+		for _, b := range backends {
+			v := value(b)
+			differ = differ || v != value(backends[0])
+			parts = append(parts, fmt.Sprintf("%s %s", b.Address, v))
+		}
+		if !differ {
+			return ""
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	var differences []string
+	if d := describe(func(b Backend) string {
+		sum := sha256.Sum256(b.Leaf.Raw)
+		return fmt.Sprintf("serves %s (sha256 %x)", subjectName(b.Leaf), sum[:8])
+	}); d != "" {
+		differences = append(differences, "Leaf certificates differ: "+d)
+	}
+	if d := describe(func(b Backend) string { return b.Trust }); d != "" {
+		differences = append(differences, "Trust differs: "+d)
+	}
+	if d := describe(func(b Backend) string { return b.Chain.Status }); d != "" {
+		differences = append(differences, "Chain verdict differs: "+d)
+	}
+	return differences
+}
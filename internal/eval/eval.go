@@ -3,23 +3,73 @@ package eval
 import (
 	"crypto/x509"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Horiodino/key-length/internal/blocklist"
 	"github.com/Horiodino/key-length/internal/config"
 	"github.com/Horiodino/key-length/internal/ecc"
+	"github.com/Horiodino/key-length/internal/parse"
 	"github.com/Horiodino/key-length/internal/rsa"
 	"github.com/Horiodino/key-length/internal/types"
 )
 
 type EvaluationResult struct {
-	Algorithm     string
-	Length        int
-	Status        string
-	Expiry        string
-	ExpiryWarning string
+	Algorithm          string
+	Length             int
+	Curve              string
+	SignatureAlgorithm string
+	Status             string
+	Expiry             string
+	ExpiryWarning      string
+	Findings           []Finding
+}
+
+// Finding and the severities are aliased here so callers only need eval.
+type Finding = types.Finding
+
+const (
+	SeverityInvalid  = types.SeverityInvalid
+	SeverityCritical = types.SeverityCritical
+	SeverityFailed   = types.SeverityFailed
+	SeverityWarning  = types.SeverityWarning
+)
+
+func (r *EvaluationResult) addFinding(check, severity, message string) {
+	r.Findings = append(r.Findings, Finding{Check: check, Severity: severity, Message: message})
+}
+
+// failed reports whether any finding is more severe than a warning.
+func (r *EvaluationResult) failed() bool {
+	for _, f := range r.Findings {
+		if f.Severity != SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// invalid reports whether the key itself is malformed.
+func (r *EvaluationResult) invalid() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityInvalid {
+			return true
+		}
+	}
+	return false
+}
+
+// Options enables checks that depend on data loaded outside the standard.
+type Options struct {
+	DebianBlocklist *rsa.DebianBlocklist
+	KeyBlocklist    *blocklist.Blocklist
 }
 
 func EvaluateKey(key types.KeyLengthEvaluator, cfg *config.Config, certData []byte) *EvaluationResult {
+	return EvaluateKeyWithOptions(key, cfg, certData, Options{})
+}
+
+func EvaluateKeyWithOptions(key types.KeyLengthEvaluator, cfg *config.Config, certData []byte, opts Options) *EvaluationResult {
 	length := key.GetLength()
 	var algorithm string
 
@@ -32,34 +82,90 @@ func EvaluateKey(key types.KeyLengthEvaluator, cfg *config.Config, certData []by
 		algorithm = "Unknown"
 	}
 
-	threshold := cfg.GetThreshold(algorithm)
-	isSecure := length >= threshold
+	result := &EvaluationResult{
+		Algorithm: algorithm,
+		Length:    length,
+		Expiry:    "N/A",
+	}
 
-	expiry := "N/A"
-	expiryWarning := ""
+	if curveKey, ok := key.(types.CurveEvaluator); ok {
+		result.Curve = curveKey.GetCurve()
+		checkCurve(result, cfg)
+	}
+
+	if holder, ok := key.(types.CertificateHolder); ok {
+		if cert := holder.Certificate(); cert != nil {
+			checkSignature(result, cert, cfg)
+		}
+	}
+	if holder, ok := key.(types.RawCertificateHolder); ok {
+		if algorithm, ok := holder.RawSignatureAlgorithm(); ok {
+			checkSignatureAlgorithm(result, algorithm, cfg)
+		}
+	}
+
+	if checker, ok := key.(types.SanityChecker); ok {
+		result.Findings = append(result.Findings, checker.Check()...)
+	}
+
+	if rsaKey, ok := key.(*rsa.RSAKey); ok && opts.DebianBlocklist != nil {
+		if f := opts.DebianBlocklist.Check(rsaKey); f != nil {
+			result.Findings = append(result.Findings, *f)
+		}
+	}
+
+	if opts.KeyBlocklist != nil {
+		if fingerprint, err := parse.Fingerprint(key); err == nil {
+			if f := opts.KeyBlocklist.Check(fingerprint); f != nil {
+				result.Findings = append(result.Findings, *f)
+			}
+		}
+	}
+
+	threshold := cfg.GetThreshold(algorithm)
+	isSecure := length >= threshold && !result.failed()
 
 	if certData != nil {
 		cert, err := x509.ParseCertificate(certData)
 		if err == nil {
-			expiry = cert.NotAfter.Format("2006-01-02")
+			result.Expiry = cert.NotAfter.Format("2006-01-02")
 			threshold := 90 * 24 * time.Hour
 			if time.Until(cert.NotAfter) < threshold {
 				daysLeft := int(time.Until(cert.NotAfter).Hours() / 24)
-				expiryWarning = fmt.Sprintf("Warning: Certificate expires in %d days (threshold: 90 days)", daysLeft)
+				result.ExpiryWarning = fmt.Sprintf("Warning: Certificate expires in %d days (threshold: 90 days)", daysLeft)
 			}
 		}
 	}
 
-	return &EvaluationResult{
-		Algorithm: algorithm,
-		Length:    length,
-		Status: fmt.Sprintf("%s (%s)", func() string {
-			if isSecure {
-				return "Secure"
-			}
-			return "Insecure"
-		}(), cfg.SelectedStandard),
-		Expiry:        expiry,
-		ExpiryWarning: expiryWarning,
+	result.Status = fmt.Sprintf("%s (%s)", func() string {
+		switch {
+		case result.invalid():
+			return "Invalid Key"
+		case isSecure:
+			return "Secure"
+		}
+		return "Insecure"
+	}(), cfg.SelectedStandard)
+
+	return result
+}
+
+func checkCurve(result *EvaluationResult, cfg *config.Config) {
+	allowed := cfg.GetStandard().AllowedCurves
+	if len(allowed) == 0 {
+		return
+	}
+
+	if result.Curve == "" {
+		result.addFinding("curve", SeverityFailed, "Curve could not be determined")
+		return
+	}
+	curve := ecc.CanonicalCurveName(result.Curve)
+	for _, name := range allowed {
+		if strings.EqualFold(curve, ecc.CanonicalCurveName(name)) {
+			return
+		}
 	}
+	result.addFinding("curve", SeverityFailed, fmt.Sprintf("Curve %s is not allowed by %s (allowed: %s)",
+		result.Curve, cfg.SelectedStandard, strings.Join(allowed, ", ")))
 }
@@ -0,0 +1,40 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/tlsprobe"
+)
+
+// EvaluateGroup checks a key exchange group against the standard's ECC
+// threshold, or its DH threshold for finite-field groups. Hybrid groups are
+// held to the ECC threshold through their classical component.
+func EvaluateGroup(group tlsprobe.Group, cfg *config.Config) *EvaluationResult {
+	result := &EvaluationResult{
+		Algorithm: group.Kind,
+		Length:    group.Bits,
+		Expiry:    "N/A",
+	}
+
+	algorithm := "ECC"
+	if group.Kind == tlsprobe.KindFFDHE {
+		algorithm = "DH"
+	}
+	threshold := cfg.GetThreshold(algorithm)
+
+	switch {
+	case group.Kind == tlsprobe.KindFFDHE && group.Bits < 1024:
+		result.addFinding("kex-group", SeverityCritical, fmt.Sprintf("DHE prime of %d bits can be broken with precomputation (Logjam)", group.Bits))
+	case group.Bits < threshold:
+		result.addFinding("kex-group", SeverityFailed, fmt.Sprintf("%s key exchange of %d bits is below the %s %s threshold of %d bits",
+			group.Name, group.Bits, cfg.SelectedStandard, algorithm, threshold))
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
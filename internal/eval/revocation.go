@@ -0,0 +1,58 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/parse"
+	"github.com/Horiodino/key-length/internal/revocation"
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+// EvaluateRevocation turns a revocation check into findings. A revoked
+// certificate is critical and an unknown status is a warning. The key that
+// signed the OCSP response or CRL is held to the standard like a
+// certificate key, and its signature hash must not have known collisions.
+func EvaluateRevocation(r revocation.Result, cfg *config.Config, opts Options) *EvaluationResult {
+	result := &EvaluationResult{Expiry: "N/A"}
+
+	if r.Signer != nil {
+		parsedKey, err := parse.ParseData(r.Signer.Raw)
+		if err != nil {
+			result.addFinding("revocation-signer", SeverityFailed, "Key that signed the revocation information could not be parsed: "+err.Error())
+		} else {
+			signer := EvaluateKeyWithOptions(parsedKey.Key.(types.KeyLengthEvaluator), cfg, nil, opts)
+			result.Algorithm = signer.Algorithm
+			result.Length = signer.Length
+			result.Curve = signer.Curve
+			if threshold := cfg.GetThreshold(signer.Algorithm); signer.Length < threshold {
+				result.addFinding("revocation-signer", SeverityFailed, fmt.Sprintf("%s %s key of %d bits is below the %s threshold of %d bits",
+					r.Source, signer.Algorithm, signer.Length, cfg.SelectedStandard, threshold))
+			}
+			for _, finding := range signer.Findings {
+				if finding.Check != "signature" {
+					result.Findings = append(result.Findings, finding)
+				}
+			}
+		}
+
+		result.SignatureAlgorithm = r.SignatureAlgorithm.String()
+		if sig, ok := signatureHashes[r.SignatureAlgorithm]; ok && sig.collisions && !hashAllowed(sig.hash, cfg.GetStandard().AllowedHashes) {
+			result.addFinding("revocation-signature", SeverityFailed, fmt.Sprintf("%s is signed with %s, which has practical collision attacks", r.Source, sig.hash))
+		}
+	}
+
+	switch r.Status {
+	case revocation.StatusRevoked:
+		result.addFinding("revocation", SeverityCritical, fmt.Sprintf("Certificate was revoked on %s", r.RevokedAt.Format("2006-01-02")))
+	case revocation.StatusUnknown:
+		result.addFinding("revocation", SeverityWarning, "Revocation status is unknown")
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
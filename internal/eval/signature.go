@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/config"
+)
+
+// signatureHash describes the hash and padding of a certificate signature.
+type signatureHash struct {
+	hash       string
+	pkcs1v15   bool
+	intrinsic  bool // the scheme fixes its own hash, as Ed25519 does
+	collisions bool // practical collisions are known
+}
+
+var signatureHashes = map[x509.SignatureAlgorithm]signatureHash{
+	x509.MD2WithRSA:       {hash: "MD2", pkcs1v15: true, collisions: true},
+	x509.MD5WithRSA:       {hash: "MD5", pkcs1v15: true, collisions: true},
+	x509.SHA1WithRSA:      {hash: "SHA-1", pkcs1v15: true, collisions: true},
+	x509.DSAWithSHA1:      {hash: "SHA-1", collisions: true},
+	x509.ECDSAWithSHA1:    {hash: "SHA-1", collisions: true},
+	x509.SHA256WithRSA:    {hash: "SHA-256", pkcs1v15: true},
+	x509.SHA384WithRSA:    {hash: "SHA-384", pkcs1v15: true},
+	x509.SHA512WithRSA:    {hash: "SHA-512", pkcs1v15: true},
+	x509.SHA256WithRSAPSS: {hash: "SHA-256"},
+	x509.SHA384WithRSAPSS: {hash: "SHA-384"},
+	x509.SHA512WithRSAPSS: {hash: "SHA-512"},
+	x509.DSAWithSHA256:    {hash: "SHA-256"},
+	x509.ECDSAWithSHA256:  {hash: "SHA-256"},
+	x509.ECDSAWithSHA384:  {hash: "SHA-384"},
+	x509.ECDSAWithSHA512:  {hash: "SHA-512"},
+	x509.PureEd25519:      {hash: "SHA-512", intrinsic: true},
+}
+
+// checkSignature evaluates the certificate's signature algorithm against the
+// standard's allowed hashes and PSS requirement. Hashes with known collisions
+// are rejected even when a standard lists no hashes.
+func checkSignature(result *EvaluationResult, cert *x509.Certificate, cfg *config.Config) {
+	// Clients trust a root by its key, not by the signature over itself.
+	if cert.IsCA && bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		result.SignatureAlgorithm = cert.SignatureAlgorithm.String()
+		return
+	}
+	checkSignatureAlgorithm(result, cert.SignatureAlgorithm, cfg)
+}
+
+// checkSignatureAlgorithm evaluates a certificate signature algorithm on its
+// own. It serves certificates crypto/x509 could not parse, whose basic
+// constraints are unknown, so a self-signed root is not exempted.
+func checkSignatureAlgorithm(result *EvaluationResult, algorithm x509.SignatureAlgorithm, cfg *config.Config) {
+	result.SignatureAlgorithm = algorithm.String()
+	standard := cfg.GetStandard()
+
+	sig, ok := signatureHashes[algorithm]
+	if !ok {
+		result.addFinding("signature", SeverityFailed, "Certificate signature algorithm is not recognised")
+		return
+	}
+
+	switch {
+	case sig.collisions && !hashAllowed(sig.hash, standard.AllowedHashes):
+		result.addFinding("signature", SeverityCritical, fmt.Sprintf("Certificate is signed with %s, which has practical collision attacks", sig.hash))
+	case !sig.intrinsic && len(standard.AllowedHashes) > 0 && !hashAllowed(sig.hash, standard.AllowedHashes):
+		result.addFinding("signature", SeverityFailed, fmt.Sprintf("Signature hash %s is not allowed by %s (allowed: %s)",
+			sig.hash, cfg.SelectedStandard, strings.Join(standard.AllowedHashes, ", ")))
+	case sig.pkcs1v15 && standard.RequirePSS != nil && *standard.RequirePSS:
+		result.addFinding("signature", SeverityFailed, fmt.Sprintf("Certificate uses RSA PKCS #1 v1.5 signature padding but %s requires RSASSA-PSS", cfg.SelectedStandard))
+	}
+}
+
+// hashAllowed compares hash names ignoring case and separators, so "SHA256"
+// matches "SHA-256".
+func hashAllowed(hash string, allowed []string) bool {
+	normalize := strings.NewReplacer("-", "", "_", "", " ", "")
+	want := normalize.Replace(strings.ToUpper(hash))
+	for _, name := range allowed {
+		if normalize.Replace(strings.ToUpper(name)) == want {
+			return true
+		}
+	}
+	return false
+}
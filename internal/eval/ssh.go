@@ -0,0 +1,250 @@
+package eval
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/parse"
+	"github.com/Horiodino/key-length/internal/sshprobe"
+	"github.com/Horiodino/key-length/internal/symmetric"
+	"github.com/Horiodino/key-length/internal/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// sha1HostKeyAlgorithms sign the key exchange with SHA-1.
+var sha1HostKeyAlgorithms = map[string]bool{
+	ssh.KeyAlgoRSA:     true,
+	ssh.KeyAlgoDSA:     true,
+	ssh.CertAlgoRSAv01: true,
+	ssh.CertAlgoDSAv01: true,
+}
+
+// EvaluateSSHHostKey evaluates an SSH host key like any other key of its
+// type. Ed25519 keys are held to the ECC threshold and DSA keys, which SSH
+// limits to 1024 bits, to the RSA threshold. A key the server only signs
+// with using SHA-1 fails; one it also offers with SHA-1 is a warning.
+func EvaluateSSHHostKey(hostKey sshprobe.HostKey, cfg *config.Config, opts Options) *EvaluationResult {
+	result := &EvaluationResult{Expiry: "N/A"}
+	threshold := 0
+
+	crypto, ok := hostKey.Key.(ssh.CryptoPublicKey)
+	if !ok {
+		result.Algorithm = hostKey.Key.Type()
+		result.addFinding("ssh-host-key", SeverityInvalid, "Host key type "+hostKey.Key.Type()+" is not supported")
+	} else {
+		switch key := crypto.CryptoPublicKey().(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			blockType := "RSA PUBLIC KEY"
+			if _, ok := key.(*ecdsa.PublicKey); ok {
+				blockType = "EC PUBLIC KEY"
+			}
+			der, err := x509.MarshalPKIXPublicKey(key)
+			var parsed *parse.ParsedKey
+			if err == nil {
+				parsed, err = parse.ParseData(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+			}
+			if err != nil {
+				result.Algorithm = hostKey.Key.Type()
+				result.addFinding("ssh-host-key", SeverityInvalid, "Host key could not be parsed: "+err.Error())
+				break
+			}
+			result = EvaluateKeyWithOptions(parsed.Key.(types.KeyLengthEvaluator), cfg, nil, opts)
+			threshold = cfg.GetThreshold(result.Algorithm)
+		case ed25519.PublicKey:
+			result.Algorithm, result.Length, result.Curve = "Ed25519", 256, "Ed25519"
+			threshold = cfg.GetThreshold("ECC")
+		case *dsa.PublicKey:
+			result.Algorithm, result.Length = "DSA", key.P.BitLen()
+			// Like a DH prime, a DSA prime is as strong as an RSA modulus of
+			// the same size.
+			threshold = cfg.GetThreshold("RSA")
+			result.addFinding("ssh-host-key", SeverityFailed, "ssh-dss keys are limited to 1024 bits and SHA-1, and are disabled since OpenSSH 7.0")
+		default:
+			result.Algorithm = hostKey.Key.Type()
+			result.addFinding("ssh-host-key", SeverityInvalid, "Host key type "+hostKey.Key.Type()+" is not supported")
+		}
+	}
+
+	result.SignatureAlgorithm = strings.Join(hostKey.Algorithms, ", ")
+	var sha1Only []string
+	for _, algorithm := range hostKey.Algorithms {
+		if sha1HostKeyAlgorithms[algorithm] {
+			sha1Only = append(sha1Only, algorithm)
+		}
+	}
+	switch {
+	case len(sha1Only) > 0 && len(sha1Only) == len(hostKey.Algorithms):
+		result.addFinding("ssh-signature", SeverityFailed, fmt.Sprintf("Host key is only offered with %s, which signs with SHA-1", strings.Join(sha1Only, ", ")))
+	case len(sha1Only) > 0:
+		result.addFinding("ssh-signature", SeverityWarning, fmt.Sprintf("Host key is also offered with %s, which signs with SHA-1", strings.Join(sha1Only, ", ")))
+	}
+
+	result.Status = fmt.Sprintf("%s (%s)", func() string {
+		switch {
+		case result.invalid():
+			return "Invalid Key"
+		case result.Length >= threshold && !result.failed():
+			return "Secure"
+		}
+		return "Insecure"
+	}(), cfg.SelectedStandard)
+	return result
+}
+
+// EvaluateSSHKeyExchange checks a key exchange method against the standard's
+// ECC threshold, or its DH threshold for finite-field groups, and its hash
+// against the standard's allowed hashes. Group exchange methods are sized by
+// the prime the server was probed for, and are a warning when Bits is zero.
+func EvaluateSSHKeyExchange(kex sshprobe.KeyExchange, cfg *config.Config) *EvaluationResult {
+	result := &EvaluationResult{
+		Algorithm: kex.Kind,
+		Length:    kex.Bits,
+		Expiry:    "N/A",
+	}
+
+	algorithm := "ECC"
+	finiteField := kex.Kind == sshprobe.KindDH || kex.Kind == sshprobe.KindGEX
+	if finiteField {
+		algorithm = "DH"
+	}
+	threshold := cfg.GetThreshold(algorithm)
+
+	switch {
+	case kex.Bits == 0:
+		result.addFinding("ssh-kex-group", SeverityWarning, "Group size could not be determined")
+	case finiteField && kex.Bits < 1024:
+		result.addFinding("ssh-kex-group", SeverityCritical, fmt.Sprintf("DH prime of %d bits can be broken with precomputation (Logjam)", kex.Bits))
+	case kex.Bits < threshold:
+		result.addFinding("ssh-kex-group", SeverityFailed, fmt.Sprintf("%s key exchange of %d bits is below the %s %s threshold of %d bits",
+			kex.Name, kex.Bits, cfg.SelectedStandard, algorithm, threshold))
+	}
+
+	allowed := cfg.GetStandard().AllowedHashes
+	if kex.Hash == "SHA-1" || (len(allowed) > 0 && !hashAllowed(kex.Hash, allowed)) {
+		result.addFinding("ssh-kex-hash", SeverityFailed, fmt.Sprintf("Exchange hash %s is not allowed by %s", kex.Hash, cfg.SelectedStandard))
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
+
+// EvaluateSSHCipher checks an SSH encryption algorithm's key against the
+// standard's Symmetric threshold and flags broken ciphers.
+func EvaluateSSHCipher(cipher sshprobe.Cipher, cfg *config.Config) *EvaluationResult {
+	key := symmetric.NewSymmetricKey(cipher.Bits)
+	result := &EvaluationResult{
+		Algorithm: cipher.Cipher,
+		Length:    key.GetLength(),
+		Expiry:    "N/A",
+	}
+
+	switch {
+	case cipher.Cipher == "NULL":
+		result.addFinding("ssh-cipher-none", SeverityCritical, "Cipher does not encrypt traffic")
+	case cipher.Cipher == "RC4":
+		result.addFinding("ssh-cipher-rc4", SeverityCritical, "RC4 has practical plaintext recovery attacks")
+	case cipher.BlockBits == 64:
+		result.addFinding("ssh-cipher-64bit-block", SeverityFailed, fmt.Sprintf("%s has a 64-bit block and is vulnerable to Sweet32", cipher.Cipher))
+	}
+
+	threshold := cfg.GetThreshold("Symmetric")
+	if cipher.Cipher != "NULL" && !key.IsSecure(threshold) {
+		result.addFinding("ssh-cipher-key-length", SeverityFailed, fmt.Sprintf("Cipher key of %d bits is below the %s Symmetric threshold of %d bits",
+			key.GetLength(), cfg.SelectedStandard, threshold))
+	}
+
+	if cipher.Mode == "CBC" {
+		result.addFinding("ssh-cipher-cbc", SeverityWarning, "CBC mode is exposed to plaintext recovery in SSH (CVE-2008-5161)")
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
+
+// EvaluateSSHMAC flags MACs with broken hashes, truncated tags, or that are
+// computed over the plaintext rather than the ciphertext.
+func EvaluateSSHMAC(mac sshprobe.MAC, cfg *config.Config) *EvaluationResult {
+	result := &EvaluationResult{
+		Algorithm: mac.Hash,
+		Length:    mac.TagBits,
+		Expiry:    "N/A",
+	}
+
+	switch mac.Hash {
+	case "NULL":
+		result.addFinding("ssh-mac-none", SeverityCritical, "Messages are not authenticated")
+	case "MD5":
+		result.addFinding("ssh-mac-hash", SeverityFailed, "HMAC-MD5 is deprecated")
+	case "SHA-1", "RIPEMD-160":
+		result.addFinding("ssh-mac-hash", SeverityWarning, fmt.Sprintf("HMAC-%s is deprecated in favour of HMAC-SHA-2", mac.Hash))
+	}
+
+	if mac.Hash != "NULL" {
+		if mac.TagBits < 128 {
+			result.addFinding("ssh-mac-tag", SeverityWarning, fmt.Sprintf("Tag is truncated to %d bits", mac.TagBits))
+		}
+		if !mac.ETM {
+			result.addFinding("ssh-mac-etm", SeverityWarning, "MAC is computed over the plaintext (encrypt-and-MAC) rather than the ciphertext")
+		}
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
+
+// EvaluateSSHProtocol checks the server as a whole: that it does not also
+// accept SSH 1, and that it is not exposed to the Terrapin prefix truncation
+// attack, which needs ChaCha20-Poly1305 or a CBC cipher with an
+// encrypt-then-MAC MAC, and no strict key exchange.
+func EvaluateSSHProtocol(algorithms *sshprobe.Algorithms, cfg *config.Config) *EvaluationResult {
+	// The software version follows the protocol version in the banner.
+	software := algorithms.Banner
+	if parts := strings.SplitN(software, "-", 3); len(parts) == 3 {
+		software = parts[2]
+	}
+	result := &EvaluationResult{Algorithm: software, Expiry: "N/A"}
+
+	if strings.HasPrefix(algorithms.Banner, "SSH-1.99-") {
+		result.addFinding("ssh-protocol", SeverityFailed, "Server also accepts SSH protocol 1")
+	}
+
+	var chacha, cbc, etm bool
+	for _, name := range algorithms.Ciphers {
+		cipher, ok := sshprobe.CipherByName(name)
+		chacha = chacha || name == "chacha20-poly1305@openssh.com"
+		cbc = cbc || (ok && cipher.Mode == "CBC")
+	}
+	for _, name := range algorithms.MACs {
+		mac, ok := sshprobe.MACByName(name)
+		etm = etm || (ok && mac.ETM)
+	}
+	if !algorithms.StrictKex && (chacha || (cbc && etm)) {
+		result.addFinding("ssh-terrapin", SeverityFailed, "Vulnerable to Terrapin prefix truncation (CVE-2023-48795): no strict key exchange with ChaCha20-Poly1305 or CBC with encrypt-then-MAC")
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
@@ -0,0 +1,82 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/symmetric"
+	"github.com/Horiodino/key-length/internal/tlsprobe"
+)
+
+// EvaluateSuite checks a negotiated cipher suite's bulk cipher key against
+// the standard's Symmetric threshold and flags broken constructions.
+func EvaluateSuite(suite tlsprobe.Suite, cfg *config.Config) *EvaluationResult {
+	key := symmetric.NewSymmetricKey(suite.KeyBits)
+	result := &EvaluationResult{
+		Algorithm: suite.BulkCipher(),
+		Length:    key.GetLength(),
+		Expiry:    "N/A",
+	}
+
+	switch {
+	case suite.Export:
+		result.addFinding("cipher-export", SeverityCritical, fmt.Sprintf("Export-grade suite limited to %d-bit keys", suite.KeyBits))
+	case suite.Cipher == "NULL":
+		result.addFinding("cipher-null", SeverityCritical, "Suite does not encrypt traffic")
+	case suite.Cipher == "RC4":
+		result.addFinding("cipher-rc4", SeverityCritical, "RC4 has practical plaintext recovery attacks and is prohibited by RFC 7465")
+	case suite.Cipher == "3DES":
+		result.addFinding("cipher-3des", SeverityFailed, "3DES has a 64-bit block and is vulnerable to Sweet32")
+	}
+
+	if suite.Anonymous() {
+		result.addFinding("cipher-anonymous", SeverityCritical, "Anonymous key exchange does not authenticate the server")
+	}
+
+	threshold := cfg.GetThreshold("Symmetric")
+	if suite.Cipher != "NULL" && !key.IsSecure(threshold) {
+		result.addFinding("cipher-key-length", SeverityFailed, fmt.Sprintf("Bulk cipher key of %d bits is below the %s Symmetric threshold of %d bits",
+			key.GetLength(), cfg.SelectedStandard, threshold))
+	}
+
+	if !suite.AEAD() && suite.Cipher != "NULL" && suite.Cipher != "RC4" {
+		result.addFinding("cipher-aead", SeverityWarning, "CBC suite is not AEAD and is exposed to padding oracles such as Lucky13")
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
+
+// EvaluateProtocol flags deprecated protocol versions and TLS 1.2 servers
+// that accept only CBC or stream suites. It returns nil for versions the
+// server does not accept.
+func EvaluateProtocol(protocol tlsprobe.Protocol, cfg *config.Config) *EvaluationResult {
+	if !protocol.Supported {
+		return nil
+	}
+	result := &EvaluationResult{Algorithm: protocol.Name, Expiry: "N/A"}
+
+	switch protocol.Version {
+	case tlsprobe.VersionTLS10, tlsprobe.VersionTLS11:
+		result.addFinding("protocol-version", SeverityFailed, fmt.Sprintf("%s is deprecated by RFC 8996", protocol.Name))
+	case tlsprobe.VersionTLS12:
+		aead := false
+		for _, suite := range protocol.Suites {
+			aead = aead || suite.AEAD()
+		}
+		if !aead {
+			result.addFinding("protocol-cbc-only", SeverityFailed, "Only CBC or stream cipher suites are accepted, with no AEAD suite")
+		}
+	}
+
+	status := "Secure"
+	if result.failed() {
+		status = "Insecure"
+	}
+	result.Status = fmt.Sprintf("%s (%s)", status, cfg.SelectedStandard)
+	return result
+}
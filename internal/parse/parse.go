@@ -1,19 +1,42 @@
 package parse
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"os"
 
 	"github.com/Horiodino/key-length/internal/ecc"
 	"github.com/Horiodino/key-length/internal/rsa"
+	"github.com/Horiodino/key-length/internal/types"
 )
 
 type ParsedKey struct {
 	Key interface{}
 }
 
+// Fingerprint returns the lowercase hex SHA-256 of the key's DER-encoded
+// SubjectPublicKeyInfo, the identifier used by badkeys and certificate pinning.
+func (p *ParsedKey) Fingerprint() (string, error) {
+	return Fingerprint(p.Key)
+}
+
+// Fingerprint returns the SPKI SHA-256 of any parsed key type.
+func Fingerprint(key interface{}) (string, error) {
+	encoder, ok := key.(types.SPKIEncoder)
+	if !ok {
+		return "", errors.New("key type does not support fingerprints")
+	}
+	spki, err := encoder.SPKI()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(spki)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func ParseFile(filename string) (*ParsedKey, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -41,6 +64,11 @@ func ParseData(data []byte) (*ParsedKey, error) {
 		case "CERTIFICATE":
 			cert, err := x509.ParseCertificate(block.Bytes)
 			if err != nil {
+				// crypto/x509 rejects certificates on curves it does not
+				// implement; the ECC parser can still identify those.
+				if key, eccErr := ecc.NewECCKey(data); eccErr == nil {
+					return &ParsedKey{Key: key}, nil
+				}
 				return nil, errors.New("failed to parse PEM certificate: " + err.Error())
 			}
 			switch cert.PublicKeyAlgorithm {
@@ -66,6 +94,9 @@ func ParseData(data []byte) (*ParsedKey, error) {
 
 	cert, err := x509.ParseCertificate(data)
 	if err != nil {
+		if key, eccErr := ecc.NewECCKey(data); eccErr == nil {
+			return &ParsedKey{Key: key}, nil
+		}
 		return nil, errors.New("failed to parse DER certificate: " + err.Error())
 	}
 	switch cert.PublicKeyAlgorithm {
@@ -0,0 +1,346 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialer connects through an HTTP CONNECT or SOCKS5 proxy, and directly to
+// hosts matched by NoProxy.
+type Dialer struct {
+	URL     *url.URL
+	NoProxy string
+	Timeout time.Duration
+}
+
+// New parses a proxy URL of the form http://[user:pass@]host[:port] or
+// socks5://[user:pass@]host[:port]. A URL without a scheme is taken as
+// HTTP. socks5h:// has the proxy resolve host names, where socks5://
+// resolves them locally. An empty URL returns a nil Dialer.
+func New(raw, noProxy string, timeout time.Duration) (*Dialer, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	var defaultPort string
+	switch u.Scheme {
+	case "http":
+		defaultPort = "8080"
+	case "socks5", "socks5h":
+		defaultPort = "1080"
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (supported: http, socks5, socks5h)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", u.Redacted())
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	return &Dialer{URL: u, NoProxy: noProxy, Timeout: timeout}, nil
+}
+
+// FromEnvironment returns the HTTPS_PROXY and NO_PROXY variables, or their
+// lowercase forms.
+func FromEnvironment() (string, string) {
+	return getenv("HTTPS_PROXY", "https_proxy"), getenv("NO_PROXY", "no_proxy")
+}
+
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Bypass reports whether host should be connected to directly under a
+// NO_PROXY list. Entries are "*", IP addresses, CIDR ranges, or domains,
+// which match themselves and their subdomains with or without a leading dot.
+func Bypass(host, noProxy string) bool {
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	addr, addrErr := netip.ParseAddr(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.Trim(entry, "[]")
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if addrErr == nil && prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if ip, err := netip.ParseAddr(entry); err == nil {
+			if addrErr == nil && ip == addr {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dial connects to address through the proxy, or directly when d is nil or
+// NoProxy matches its host.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	timeout := 5 * time.Second
+	if d != nil && d.Timeout > 0 {
+		timeout = d.Timeout
+	}
+	if d == nil || Bypass(host, d.NoProxy) {
+		return net.DialTimeout(network, address, timeout)
+	}
+
+	conn, err := net.DialTimeout("tcp", d.URL.Host, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", d.URL.Host, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	tunnel := conn
+	switch d.URL.Scheme {
+	case "http":
+		tunnel, err = d.connect(conn, address)
+	default:
+		err = d.socks5(conn, host, port, timeout)
+	}
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", d.URL.Host, err)
+	}
+	return tunnel, nil
+}
+
+// connect asks an HTTP proxy for a tunnel with CONNECT.
+func (d *Dialer) connect(conn net.Conn, address string) (net.Conn, error) {
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if user := d.URL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	response, err := http.ReadResponse(r, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT %s refused: %s", address, response.Status)
+	}
+	if r.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads what the proxy sent after its reply before reading
+// from the connection again.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+const (
+	socksVersion       = 0x05
+	socksNoAuth        = 0x00
+	socksPasswordAuth  = 0x02
+	socksNoAcceptable  = 0xff
+	socksConnect       = 0x01
+	socksAddressIPv4   = 0x01
+	socksAddressDomain = 0x03
+	socksAddressIPv6   = 0x04
+)
+
+var socksReplies = map[byte]string{
+	0x01: "general failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5 asks a SOCKS5 proxy to connect to host and port (RFC 1928), with
+// username and password authentication when the URL has them (RFC 1929).
+func (d *Dialer) socks5(conn net.Conn, host, port string, timeout time.Duration) error {
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+
+	methods := []byte{socksNoAuth}
+	if d.URL.User != nil {
+		methods = []byte{socksPasswordAuth}
+	}
+	if _, err := conn.Write(append([]byte{socksVersion, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion {
+		return fmt.Errorf("not a SOCKS5 proxy (version %d)", reply[0])
+	}
+	switch reply[1] {
+	case socksNoAuth:
+	case socksPasswordAuth:
+		if err := d.socksAuthenticate(conn); err != nil {
+			return err
+		}
+	case socksNoAcceptable:
+		return errors.New("SOCKS5 proxy accepts none of the offered authentication methods")
+	default:
+		return fmt.Errorf("SOCKS5 proxy chose unsupported authentication method %d", reply[1])
+	}
+
+	request := []byte{socksVersion, socksConnect, 0}
+	addr, err := netip.ParseAddr(host)
+	if err != nil && d.URL.Scheme == "socks5" {
+		addr, err = resolve(host, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	switch {
+	case err != nil:
+		if len(host) > 255 {
+			return fmt.Errorf("host name %q is too long for SOCKS5", host)
+		}
+		request = append(request, socksAddressDomain, byte(len(host)))
+		request = append(request, host...)
+	case addr.Is4():
+		request = append(request, socksAddressIPv4)
+		request = append(request, addr.AsSlice()...)
+	default:
+		request = append(request, socksAddressIPv6)
+		request = append(request, addr.AsSlice()...)
+	}
+	request = append(request, byte(portNumber>>8), byte(portNumber))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0 {
+		reason, ok := socksReplies[header[1]]
+		if !ok {
+			reason = fmt.Sprintf("reply code %d", header[1])
+		}
+		return fmt.Errorf("SOCKS5 connect to %s refused: %s", net.JoinHostPort(host, port), reason)
+	}
+
+	var skip int
+	switch header[3] {
+	case socksAddressIPv4:
+		skip = 4
+	case socksAddressIPv6:
+		skip = 16
+	case socksAddressDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		skip = int(length[0])
+	default:
+		return fmt.Errorf("SOCKS5 reply has unknown address type %d", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip+2))
+	return err
+}
+
+func (d *Dialer) socksAuthenticate(conn net.Conn) error {
+	user := d.URL.User
+	if user == nil {
+		return errors.New("SOCKS5 proxy requires a username and password")
+	}
+	password, _ := user.Password()
+	if len(user.Username()) > 255 || len(password) > 255 {
+		return errors.New("SOCKS5 username or password is too long")
+	}
+	request := []byte{0x01, byte(len(user.Username()))}
+	request = append(request, user.Username()...)
+	request = append(request, byte(len(password)))
+	request = append(request, password...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return errors.New("SOCKS5 authentication failed")
+	}
+	return nil
+}
+
+func resolve(host string, timeout time.Duration) (netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if len(addrs) == 0 {
+		return netip.Addr{}, fmt.Errorf("no addresses found for %s", host)
+	}
+	return addrs[0].Unmap(), nil
+}
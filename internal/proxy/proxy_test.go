@@ -0,0 +1,279 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen accepts connections on a loopback port and hands each to handle.
+func listen(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// echoServer stands in for the TLS server behind the proxy.
+func echoServer(t *testing.T) string {
+	return listen(t, func(conn net.Conn) { io.Copy(conn, conn) })
+}
+
+// relay dials target and copies in both directions until either side closes.
+func relay(client net.Conn, r io.Reader, target string) {
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	go io.Copy(upstream, r)
+	io.Copy(client, upstream)
+}
+
+// connectProxy stands in for an HTTP proxy that requires the given
+// Proxy-Authorization value when it is not empty.
+func connectProxy(t *testing.T, wantAuth string) string {
+	return listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		request, err := http.ReadRequest(r)
+		if err != nil || request.Method != http.MethodConnect {
+			io.WriteString(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			return
+		}
+		if wantAuth != "" && request.Header.Get("Proxy-Authorization") != wantAuth {
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		relay(conn, r, request.Host)
+	})
+}
+
+// socksProxy stands in for a SOCKS5 proxy. With a username it requires
+// password authentication. Domain names are resolved to loopback.
+func socksProxy(t *testing.T, username, password string, targets chan<- string) string {
+	return listen(t, func(conn net.Conn) {
+		greeting := make([]byte, 2)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		methods := make([]byte, greeting[1])
+		io.ReadFull(conn, methods)
+
+		want := byte(socksNoAuth)
+		if username != "" {
+			want = socksPasswordAuth
+		}
+		if !strings.ContainsRune(string(methods), rune(want)) {
+			conn.Write([]byte{socksVersion, socksNoAcceptable})
+			return
+		}
+		conn.Write([]byte{socksVersion, want})
+
+		if username != "" {
+			header := make([]byte, 2)
+			io.ReadFull(conn, header)
+			user := make([]byte, header[1])
+			io.ReadFull(conn, user)
+			length := make([]byte, 1)
+			io.ReadFull(conn, length)
+			pass := make([]byte, length[0])
+			io.ReadFull(conn, pass)
+			if string(user) != username || string(pass) != password {
+				conn.Write([]byte{0x01, 0x01})
+				return
+			}
+			conn.Write([]byte{0x01, 0x00})
+		}
+
+		request := make([]byte, 4)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		var host string
+		switch request[3] {
+		case socksAddressIPv4:
+			ip := make([]byte, 4)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socksAddressDomain:
+			length := make([]byte, 1)
+			io.ReadFull(conn, length)
+			name := make([]byte, length[0])
+			io.ReadFull(conn, name)
+			host = string(name)
+		}
+		portBytes := make([]byte, 2)
+		io.ReadFull(conn, portBytes)
+		port := strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))
+		targets <- net.JoinHostPort(host, port)
+
+		if host == "refused.example" {
+			conn.Write([]byte{socksVersion, 0x05, 0, socksAddressIPv4, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		if request[3] == socksAddressDomain {
+			host = "127.0.0.1"
+		}
+		conn.Write([]byte{socksVersion, 0, 0, socksAddressIPv4, 127, 0, 0, 1, 0, 0})
+		relay(conn, conn, net.JoinHostPort(host, port))
+	})
+}
+
+// roundTrip dials address through d and checks data reaches the echo server.
+func roundTrip(d *Dialer, address string) error {
+	conn, err := d.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.WriteString(conn, "ping"); err != nil {
+		return err
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if string(reply) != "ping" {
+		return fmt.Errorf("echo returned %q", reply)
+	}
+	return nil
+}
+
+func TestHTTPConnect(t *testing.T) {
+	backend := echoServer(t)
+
+	open := connectProxy(t, "")
+	d, err := New("http://"+open, "", time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := roundTrip(d, backend); err != nil {
+		t.Errorf("CONNECT without auth: %v", err)
+	}
+
+	authenticated := connectProxy(t, "Basic dXNlcjpzM2NyZXQ=")
+	d, _ = New("http://user:s3cret@"+authenticated, "", time.Second)
+	if err := roundTrip(d, backend); err != nil {
+		t.Errorf("CONNECT with auth: %v", err)
+	}
+
+	d, _ = New(authenticated, "", time.Second)
+	if err := roundTrip(d, backend); err == nil || !strings.Contains(err.Error(), "407") {
+		t.Errorf("Expected a 407 error without credentials, got %v", err)
+	}
+}
+
+func TestSOCKS5(t *testing.T) {
+	backend := echoServer(t)
+	_, port, _ := net.SplitHostPort(backend)
+	targets := make(chan string, 4)
+
+	open := socksProxy(t, "", "", targets)
+	d, _ := New("socks5://"+open, "", time.Second)
+	if err := roundTrip(d, backend); err != nil {
+		t.Errorf("SOCKS5 without auth: %v", err)
+	}
+	if got := <-targets; got != backend {
+		t.Errorf("Proxy was asked for %s, expected %s", got, backend)
+	}
+
+	authenticated := socksProxy(t, "user", "s3cret", targets)
+	d, _ = New("socks5h://user:s3cret@"+authenticated, "", time.Second)
+	if err := roundTrip(d, net.JoinHostPort("backend.example", port)); err != nil {
+		t.Errorf("SOCKS5 with auth: %v", err)
+	}
+	if got, want := <-targets, net.JoinHostPort("backend.example", port); got != want {
+		t.Errorf("Proxy was asked for %s, expected the unresolved %s", got, want)
+	}
+
+	d, _ = New("socks5h://user:wrong@"+authenticated, "", time.Second)
+	if err := roundTrip(d, backend); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+
+	d, _ = New("socks5h://"+authenticated, "", time.Second)
+	if err := roundTrip(d, backend); err == nil || !strings.Contains(err.Error(), "none of the offered") {
+		t.Errorf("Expected a method error without credentials, got %v", err)
+	}
+
+	d, _ = New("socks5h://"+open, "", time.Second)
+	if err := roundTrip(d, "refused.example:443"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected the proxy's refusal, got %v", err)
+	}
+	<-targets
+}
+
+func TestNoProxy(t *testing.T) {
+	backend := echoServer(t)
+	d, _ := New("http://127.0.0.1:1", "127.0.0.0/8", time.Second)
+	if err := roundTrip(d, backend); err != nil {
+		t.Errorf("Expected a direct connection for a NO_PROXY address, got %v", err)
+	}
+
+	testCases := []struct {
+		host    string
+		noProxy string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"www.example.com", ".example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com", "other.com, example.com:443", true},
+		{"10.1.2.3", "10.0.0.0/8", true},
+		{"192.0.2.1", "10.0.0.0/8,192.0.2.1", true},
+		{"2001:db8::1", "[2001:db8::1]", true},
+		{"2001:db8::1", "2001:db8::/32", true},
+		{"anything", "*", true},
+		{"example.com", "", false},
+	}
+	for _, tc := range testCases {
+		if got := Bypass(tc.host, tc.noProxy); got != tc.want {
+			t.Errorf("Bypass(%q, %q) = %v, expected %v", tc.host, tc.noProxy, got, tc.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	d, err := New("proxy.internal", "", 0)
+	if err != nil || d.URL.Scheme != "http" || d.URL.Host != "proxy.internal:8080" {
+		t.Errorf("Expected http://proxy.internal:8080, got %v (%v)", d, err)
+	}
+	d, err = New("socks5://proxy.internal", "", 0)
+	if err != nil || d.URL.Host != "proxy.internal:1080" {
+		t.Errorf("Expected port 1080 for SOCKS5, got %v (%v)", d, err)
+	}
+	if d, err := New("", "", 0); d != nil || err != nil {
+		t.Errorf("Expected no dialer for an empty URL, got %v (%v)", d, err)
+	}
+	for _, raw := range []string{"ftp://proxy.internal", "http://", "http://[::1"} {
+		if _, err := New(raw, "", 0); err == nil {
+			t.Errorf("New(%q) expected an error", raw)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package report encodes the results of scan, tls and ssh in a stable, versioned
+// schema, as JSON, YAML or CSV, for other tools to consume.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Horiodino/key-length/internal/eval"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is bumped whenever a field is renamed, removed or changes
+// meaning. Adding a field does not change it.
+//
+// The status of a result is one of a fixed set of values, without the
+// standard, which is a field of the report:
+//
+//   - an evaluation: Secure, Insecure or Invalid Key
+//   - a shared prime: Critical, or Warning for a shared modulus
+//   - trust: Trusted, Untrusted Root, Hostname Mismatch, Expired Certificate,
+//     Expired Intermediate, Missing Intermediate or Invalid Chain
+//   - client auth: Not Requested, Requested, Client Cert Required, Client
+//     Cert Accepted or Client Cert Rejected
+//   - backends: Backends Differ
+//   - a protocol that is not offered: Not Offered
+//   - a host key or algorithm that was not evaluated: Not Probed or Unknown
+//   - something that could not be evaluated: Read Failed, Parse Failed,
+//     Parsing Failed, Evaluation Failed, Resolution Failed, Connection
+//     Failed, No Certificate, Enumeration Failed or Probe Failed
+//
+// Adding a value does not change SchemaVersion.
+const SchemaVersion = "1"
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+)
+
+// Kinds of result.
+const (
+	KindKey         = "key"
+	KindCertificate = "certificate"
+	KindSharedPrime = "shared-prime"
+	KindFile        = "file"
+	KindConnection  = "connection"
+	KindClientAuth  = "client-auth"
+	KindTrust       = "trust"
+	KindChain       = "chain"
+	KindRevocation  = "revocation"
+	KindBackends    = "backends"
+	KindProtocol    = "protocol"
+	KindSuite       = "suite"
+	KindGroup       = "group"
+	KindHostKey     = "host-key"
+	KindKeyExchange = "key-exchange"
+	KindCipher      = "cipher"
+	KindMAC         = "mac"
+)
+
+// ParseFormat validates an --output value.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case FormatText, FormatJSON, FormatYAML, FormatCSV:
+		return format, nil
+	}
+	return "", errors.New("unsupported output format " + strconv.Quote(format) + ": must be text, json, yaml or csv")
+}
+
+// Report is the document written for one run.
+type Report struct {
+	SchemaVersion string    `json:"schema_version" yaml:"schema_version"`
+	Command       string    `json:"command" yaml:"command"`
+	Standard      string    `json:"standard" yaml:"standard"`
+	GeneratedAt   time.Time `json:"generated_at" yaml:"generated_at"`
+	Results       []Result  `json:"results" yaml:"results"`
+	Summary       Summary   `json:"summary" yaml:"summary"`
+}
+
+// New returns an empty report for command run against standard.
+func New(command, standard string) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Command:       command,
+		Standard:      standard,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Results:       []Result{},
+	}
+}
+
+// Result is one evaluated key, certificate or algorithm, or a file or
+// target that could not be evaluated. Source is the file path or host, and
+// the fields from Algorithm to Findings are those of eval.EvaluationResult.
+type Result struct {
+	Source   string `json:"source" yaml:"source"`
+	Address  string `json:"address,omitempty" yaml:"address,omitempty"`
+	Port     string `json:"port,omitempty" yaml:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Subject  string `json:"subject,omitempty" yaml:"subject,omitempty"`
+
+	Algorithm          string    `json:"algorithm" yaml:"algorithm"`
+	Length             int       `json:"length" yaml:"length"`
+	Curve              string    `json:"curve" yaml:"curve"`
+	SignatureAlgorithm string    `json:"signature_algorithm" yaml:"signature_algorithm"`
+	Status             string    `json:"status" yaml:"status"`
+	Expiry             string    `json:"expiry" yaml:"expiry"`
+	ExpiryWarning      string    `json:"expiry_warning" yaml:"expiry_warning"`
+	Findings           []Finding `json:"findings" yaml:"findings"`
+
+	Details string `json:"details,omitempty" yaml:"details,omitempty"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Finding is an eval.Finding.
+type Finding struct {
+	Check    string `json:"check" yaml:"check"`
+	Severity string `json:"severity" yaml:"severity"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// Summary counts the files or targets of the run, as they go into its exit
+// code.
+type Summary struct {
+	Evaluated  int `json:"evaluated" yaml:"evaluated"`
+	Insecure   int `json:"insecure" yaml:"insecure"`
+	Warned     int `json:"warned" yaml:"warned"`
+	Incomplete int `json:"incomplete" yaml:"incomplete"`
+	Errors     int `json:"errors" yaml:"errors"`
+	ExitCode   int `json:"exit_code" yaml:"exit_code"`
+}
+
+// Evaluated returns a copy of r of the given kind and name holding every
+// field of result.
+func (r Result) Evaluated(kind, name string, result *eval.EvaluationResult) Result {
+	r.Kind, r.Name = kind, name
+	r.Algorithm = result.Algorithm
+	r.Length = result.Length
+	r.Curve = result.Curve
+	r.SignatureAlgorithm = result.SignatureAlgorithm
+	r.Status = bareStatus(result.Status)
+	r.Expiry = result.Expiry
+	r.ExpiryWarning = result.ExpiryWarning
+	r.Findings = make([]Finding, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		r.Findings = append(r.Findings, Finding{Check: f.Check, Severity: f.Severity, Message: f.Message})
+	}
+	return r
+}
+
+// Noted returns a copy of r of the given kind and name with a status that is
+// not an evaluation, such as whether a chain is trusted.
+func (r Result) Noted(kind, name, status, details string) Result {
+	r.Kind, r.Name, r.Status, r.Details = kind, name, bareStatus(status), details
+	r.Findings = []Finding{}
+	return r
+}
+
+// Failed returns a copy of r of the given kind for something that could not
+// be evaluated.
+func (r Result) Failed(kind, status string, err error) Result {
+	r.Kind, r.Status = kind, status
+	r.Findings = []Finding{}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// bareStatus drops the standard an evaluation status is suffixed with, as
+// in "Secure (NIST)".
+func bareStatus(status string) string {
+	if i := strings.LastIndex(status, " ("); i >= 0 && strings.HasSuffix(status, ")") {
+		return status[:i]
+	}
+	return status
+}
+
+// Write encodes the report in format.
+func Write(w io.Writer, format string, r *Report) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatCSV:
+		return writeCSV(w, r)
+	}
+	return errors.New("unsupported output format " + strconv.Quote(format))
+}
+
+// csvHeader is the first row of CSV output. Its columns follow the JSON
+// field names, with findings flattened into one column.
+var csvHeader = []string{
+	"source", "address", "port", "protocol", "kind", "name", "subject",
+	"algorithm", "length", "curve", "signature_algorithm", "status",
+	"expiry", "expiry_warning", "findings", "details", "error",
+}
+
+// writeCSV writes one row per result. Findings are joined as
+// "Severity check: message" separated by "; ".
+func writeCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		findings := make([]string, 0, len(result.Findings))
+		for _, f := range result.Findings {
+			findings = append(findings, f.Severity+" "+f.Check+": "+f.Message)
+		}
+		length := ""
+		if result.Length > 0 {
+			length = strconv.Itoa(result.Length)
+		}
+		row := []string{
+			result.Source, result.Address, result.Port, result.Protocol, result.Kind, result.Name, result.Subject,
+			result.Algorithm, length, result.Curve, result.SignatureAlgorithm, result.Status,
+			result.Expiry, result.ExpiryWarning, strings.Join(findings, "; "), result.Details, result.Error,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Horiodino/key-length/internal/eval"
+	"gopkg.in/yaml.v3"
+)
+
+func sampleReport() *Report {
+	r := New("tls", "NIST")
+	base := Result{Source: "example.com", Port: "443"}
+	r.Results = append(r.Results,
+		base.Evaluated(KindCertificate, "Leaf", &eval.EvaluationResult{
+			Algorithm:          "RSA",
+			Length:             1024,
+			SignatureAlgorithm: "SHA1-RSA",
+			Status:             "Insecure (NIST)",
+			Expiry:             "2030-01-01",
+			Findings: []eval.Finding{
+				{Check: "signature", Severity: eval.SeverityFailed, Message: "SHA-1, with \"collisions\""},
+				{Check: "transition", Severity: eval.SeverityWarning, Message: "Disallowed after 2030"},
+			},
+		}),
+		base.Noted(KindTrust, "", "Trusted", "Chain verified"),
+		Result{Source: "example.com", Port: "8443"}.Failed(KindConnection, "Connection Failed", errors.New("connection refused")),
+	)
+	r.Summary = Summary{Evaluated: 1, Insecure: 1, Errors: 1, ExitCode: 4}
+	return r
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range []string{"text", "json", "yaml", "csv"} {
+		if _, err := ParseFormat(format); err != nil {
+			t.Errorf("ParseFormat(%q) error: %v", format, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for xml")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, sampleReport()); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not JSON: %v", err)
+	}
+	if decoded["schema_version"] != SchemaVersion {
+		t.Errorf("Expected schema_version %s, got %v", SchemaVersion, decoded["schema_version"])
+	}
+
+	results := decoded["results"].([]any)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	// Every field of EvaluationResult is present, even when empty.
+	for _, result := range results {
+		fields := result.(map[string]any)
+		for _, key := range []string{"source", "kind", "algorithm", "length", "curve", "signature_algorithm", "status", "expiry", "expiry_warning", "findings"} {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("Result %v is missing %s", fields, key)
+			}
+		}
+		if fields["findings"] == nil {
+			t.Errorf("Expected findings to be a list, got null in %v", fields)
+		}
+	}
+	// The standard is a field of the report, not part of the status.
+	if status := results[0].(map[string]any)["status"]; status != "Insecure" {
+		t.Errorf("Expected status Insecure, got %v", status)
+	}
+	if results[2].(map[string]any)["error"] != "connection refused" {
+		t.Errorf("Expected the connection error, got %v", results[2])
+	}
+	if summary := decoded["summary"].(map[string]any); summary["exit_code"] != float64(4) {
+		t.Errorf("Expected exit_code 4, got %v", summary)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatYAML, sampleReport()); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	var decoded Report
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not YAML: %v", err)
+	}
+	want := sampleReport()
+	if decoded.SchemaVersion != SchemaVersion || decoded.Command != want.Command || len(decoded.Results) != len(want.Results) {
+		t.Fatalf("Report did not round-trip: %+v", decoded)
+	}
+	leaf := decoded.Results[0]
+	if leaf.Length != 1024 || leaf.Status != "Insecure" || len(leaf.Findings) != 2 || leaf.Findings[0].Check != "signature" {
+		t.Errorf("Leaf did not round-trip: %+v", leaf)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, sampleReport()); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected a header and 3 rows, got %d", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		t.Errorf("Unexpected header %v", rows[0])
+	}
+
+	column := map[string]int{}
+	for i, name := range rows[0] {
+		column[name] = i
+	}
+	leaf := rows[1]
+	if leaf[column["length"]] != "1024" || leaf[column["status"]] != "Insecure" {
+		t.Errorf("Unexpected leaf row %v", leaf)
+	}
+	wantFindings := `Failed signature: SHA-1, with "collisions"; Warning transition: Disallowed after 2030`
+	if leaf[column["findings"]] != wantFindings {
+		t.Errorf("Expected findings %q, got %q", wantFindings, leaf[column["findings"]])
+	}
+	if failed := rows[3]; failed[column["length"]] != "" || failed[column["error"]] != "connection refused" {
+		t.Errorf("Unexpected failed row %v", failed)
+	}
+}
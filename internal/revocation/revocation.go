@@ -0,0 +1,239 @@
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	StatusGood    = "Good"
+	StatusRevoked = "Revoked"
+	StatusUnknown = "Unknown"
+
+	SourceStapled = "Stapled OCSP"
+	SourceOCSP    = "OCSP"
+	SourceCRL     = "CRL"
+)
+
+// maxResponseSize bounds OCSP responses and CRLs read from the network.
+const maxResponseSize = 10 << 20
+
+// clockSkew is how far in the future a response's thisUpdate may be.
+const clockSkew = 5 * time.Minute
+
+// Result is the revocation status of a certificate, where it came from, and
+// the certificate whose key signed the answer. Signer and
+// SignatureAlgorithm are unset when Status is StatusUnknown because no
+// source answered.
+type Result struct {
+	Status             string
+	Source             string
+	Message            string
+	RevokedAt          time.Time
+	Signer             *x509.Certificate
+	SignatureAlgorithm x509.SignatureAlgorithm
+}
+
+// Checker fetches OCSP responses and CRLs with Client, or a client with a
+// ten second timeout when Client is nil.
+type Checker struct {
+	Client *http.Client
+}
+
+// Check returns the status of cert from the stapled OCSP response if there is
+// one, and otherwise from cert's OCSP responders and then its CRL
+// distribution points. An Unknown answer or a failed source falls through
+// to the next; the failures are kept in the message if none answers.
+func (c *Checker) Check(cert, issuer *x509.Certificate, stapled []byte, now time.Time) Result {
+	if issuer == nil {
+		return Result{Status: StatusUnknown, Message: "Issuer certificate is not available to verify revocation information"}
+	}
+
+	var problems []string
+	if len(stapled) > 0 {
+		result, err := checkOCSP(stapled, cert, issuer, now)
+		if err == nil && result.Status != StatusUnknown {
+			result.Source = SourceStapled
+			return result
+		}
+		problems = append(problems, describe(SourceStapled, "", result, err))
+	}
+
+	for _, server := range cert.OCSPServer {
+		der, err := c.queryOCSP(server, cert, issuer)
+		var result Result
+		if err == nil {
+			result, err = checkOCSP(der, cert, issuer, now)
+		}
+		if err == nil && result.Status != StatusUnknown {
+			result.Source = SourceOCSP
+			result.Message = "Responder " + server
+			return result
+		}
+		problems = append(problems, describe(SourceOCSP, server, result, err))
+	}
+
+	for _, point := range cert.CRLDistributionPoints {
+		if !strings.HasPrefix(point, "http://") && !strings.HasPrefix(point, "https://") {
+			continue
+		}
+		der, err := c.fetch(point)
+		var result Result
+		if err == nil {
+			result, err = checkCRL(der, cert, issuer, now)
+		}
+		if err == nil {
+			result.Source = SourceCRL
+			result.Message = "CRL " + point
+			return result
+		}
+		problems = append(problems, describe(SourceCRL, point, result, err))
+	}
+
+	if len(problems) == 0 {
+		return Result{Status: StatusUnknown, Message: "No stapled OCSP response, OCSP responder or CRL distribution point"}
+	}
+	return Result{Status: StatusUnknown, Message: strings.Join(problems, "; ")}
+}
+
+func describe(source, location string, result Result, err error) string {
+	if location != "" {
+		source += " " + location
+	}
+	if err != nil {
+		return fmt.Sprintf("%s: %v", source, err)
+	}
+	return source + ": status unknown"
+}
+
+// checkOCSP parses and verifies a response for cert. The response must be
+// signed by issuer, or by a responder certificate that issuer signed for
+// OCSP signing, and must be current.
+func checkOCSP(der []byte, cert, issuer *x509.Certificate, now time.Time) (Result, error) {
+	response, err := ocsp.ParseResponseForCert(der, cert, issuer)
+	if err != nil {
+		return Result{}, err
+	}
+
+	signer := issuer
+	if response.Certificate != nil && !bytes.Equal(response.Certificate.Raw, issuer.Raw) {
+		signer = response.Certificate
+		if !hasUsage(signer, x509.ExtKeyUsageOCSPSigning) {
+			return Result{}, errors.New("delegated responder certificate is not authorised for OCSP signing")
+		}
+	}
+	if err := checkValidity(response.ThisUpdate, response.NextUpdate, now); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Signer: signer, SignatureAlgorithm: response.SignatureAlgorithm}
+	switch response.Status {
+	case ocsp.Good:
+		result.Status = StatusGood
+	case ocsp.Revoked:
+		result.Status = StatusRevoked
+		result.RevokedAt = response.RevokedAt
+	default:
+		result.Status = StatusUnknown
+	}
+	return result, nil
+}
+
+// checkCRL parses a DER or PEM CRL, verifies it was signed by issuer and is
+// current, and looks for cert's serial number in it.
+func checkCRL(data []byte, cert, issuer *x509.Certificate, now time.Time) (Result, error) {
+	if block, _ := pem.Decode(data); block != nil && block.Type == "X509 CRL" {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return Result{}, fmt.Errorf("bad CRL signature: %w", err)
+	}
+	if err := checkValidity(crl.ThisUpdate, crl.NextUpdate, now); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Status: StatusGood, Signer: issuer, SignatureAlgorithm: crl.SignatureAlgorithm}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			result.Status = StatusRevoked
+			result.RevokedAt = entry.RevocationTime
+			break
+		}
+	}
+	return result, nil
+}
+
+func checkValidity(thisUpdate, nextUpdate, now time.Time) error {
+	if thisUpdate.After(now.Add(clockSkew)) {
+		return fmt.Errorf("not valid until %s", thisUpdate.Format(time.RFC3339))
+	}
+	if !nextUpdate.IsZero() && now.After(nextUpdate) {
+		return fmt.Errorf("expired at %s", nextUpdate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func hasUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// queryOCSP POSTs a request for cert to server (RFC 6960, Appendix A).
+func (c *Checker) queryOCSP(server string, cert, issuer *x509.Certificate) ([]byte, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.client().Post(server, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	return readBody(response)
+}
+
+func (c *Checker) fetch(url string) ([]byte, error) {
+	response, err := c.client().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	return readBody(response)
+}
+
+func readBody(response *http.Response) ([]byte, error) {
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", response.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("response larger than %d bytes", maxResponseSize)
+	}
+	return body, nil
+}
@@ -0,0 +1,232 @@
+package revocation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func issue(t *testing.T, template *x509.Certificate, parent *issued) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	template.BasicConstraintsValid = true
+	issuerCert, issuerKey := template, key
+	if parent != nil {
+		issuerCert, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &issued{cert: cert, key: key}
+}
+
+// responder answers OCSP requests and serves a CRL, with status and signer
+// set per test.
+type responder struct {
+	t          *testing.T
+	ca         *issued
+	signer     *issued
+	status     int
+	nextUpdate time.Time
+	revoked    []*big.Int
+	requests   int
+}
+
+func (r *responder) ocspResponse(serial *big.Int) []byte {
+	r.t.Helper()
+	signer := r.signer
+	if signer == nil {
+		signer = r.ca
+	}
+	template := ocsp.Response{
+		Status:       r.status,
+		SerialNumber: serial,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   r.nextUpdate,
+		RevokedAt:    time.Now().Add(-time.Hour),
+	}
+	if signer != r.ca {
+		template.Certificate = signer.cert
+	}
+	der, err := ocsp.CreateResponse(r.ca.cert, signer.cert, template, signer.key)
+	if err != nil {
+		r.t.Fatalf("Failed to create OCSP response: %v", err)
+	}
+	return der
+}
+
+func (r *responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/ocsp":
+		r.requests++
+		body, _ := io.ReadAll(req.Body)
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(r.ocspResponse(request.SerialNumber))
+	case "/ca.crl":
+		var entries []x509.RevocationListEntry
+		for _, serial := range r.revoked {
+			entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: time.Now().Add(-time.Hour)})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now().Add(-time.Minute),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: entries,
+		}, r.ca.cert, r.ca.key)
+		if err != nil {
+			r.t.Fatalf("Failed to create CRL: %v", err)
+		}
+		w.Write(der)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ca := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test CA"},
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil)
+	other := issue(t, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "Other CA"}, IsCA: true}, nil)
+	delegate := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "OCSP Responder"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}, ca)
+	notDelegate := issue(t, &x509.Certificate{SerialNumber: big.NewInt(4), Subject: pkix.Name{CommonName: "Web Server"}}, ca)
+
+	r := &responder{t: t, ca: ca}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	leafWith := func(ocspServers, crls []string) *x509.Certificate {
+		return issue(t, &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: "www.example.com"},
+			OCSPServer:            ocspServers,
+			CRLDistributionPoints: crls,
+		}, ca).cert
+	}
+	leaf := leafWith([]string{server.URL + "/ocsp"}, []string{server.URL + "/ca.crl"})
+	crlOnly := leafWith(nil, []string{server.URL + "/ca.crl"})
+	noSources := leafWith(nil, nil)
+
+	staple := func(status int, signer *issued, nextUpdate time.Time) []byte {
+		saved := *r
+		r.status, r.signer, r.nextUpdate = status, signer, nextUpdate
+		defer func() { *r = saved }()
+		return r.ocspResponse(leaf.SerialNumber)
+	}
+
+	testCases := []struct {
+		name       string
+		cert       *x509.Certificate
+		issuer     *x509.Certificate
+		stapled    []byte
+		status     int
+		signer     *issued
+		nextUpdate time.Time
+		revoked    bool
+		wantStatus string
+		wantSource string
+		wantSigner string
+		wantMsg    string
+	}{
+		{"StapledGood", leaf, ca.cert, staple(ocsp.Good, nil, time.Time{}), ocsp.Revoked, nil, time.Time{}, false, StatusGood, SourceStapled, "Test CA", ""},
+		{"StapledRevoked", leaf, ca.cert, staple(ocsp.Revoked, nil, time.Time{}), ocsp.Good, nil, time.Time{}, false, StatusRevoked, SourceStapled, "Test CA", ""},
+		{"StapledWrongSigner", leaf, ca.cert, staple(ocsp.Good, other, time.Time{}), ocsp.Good, nil, time.Time{}, false, StatusGood, SourceOCSP, "Test CA", ""},
+		{"StapledExpired", leaf, ca.cert, staple(ocsp.Good, nil, time.Now().Add(-time.Minute)), ocsp.Revoked, nil, time.Time{}, false, StatusRevoked, SourceOCSP, "Test CA", ""},
+		{"ResponderGood", leaf, ca.cert, nil, ocsp.Good, nil, time.Time{}, false, StatusGood, SourceOCSP, "Test CA", ""},
+		{"ResponderRevoked", leaf, ca.cert, nil, ocsp.Revoked, nil, time.Time{}, false, StatusRevoked, SourceOCSP, "Test CA", ""},
+		{"DelegatedResponder", leaf, ca.cert, nil, ocsp.Good, delegate, time.Time{}, false, StatusGood, SourceOCSP, "OCSP Responder", ""},
+		{"UnauthorisedResponderFallsBackToCRL", leaf, ca.cert, nil, ocsp.Good, notDelegate, time.Time{}, true, StatusRevoked, SourceCRL, "Test CA", ""},
+		{"ResponderUnknownFallsBackToCRL", leaf, ca.cert, nil, ocsp.Unknown, nil, time.Time{}, false, StatusGood, SourceCRL, "Test CA", ""},
+		{"CRLRevoked", crlOnly, ca.cert, nil, ocsp.Good, nil, time.Time{}, true, StatusRevoked, SourceCRL, "Test CA", ""},
+		{"CRLWrongIssuer", crlOnly, other.cert, nil, ocsp.Good, nil, time.Time{}, false, StatusUnknown, "", "", "bad CRL signature"},
+		{"NoSources", noSources, ca.cert, nil, ocsp.Good, nil, time.Time{}, false, StatusUnknown, "", "", "No stapled OCSP response"},
+		{"NoIssuer", leaf, nil, nil, ocsp.Good, nil, time.Time{}, false, StatusUnknown, "", "", "Issuer certificate is not available"},
+	}
+
+	checker := &Checker{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r.status, r.signer, r.nextUpdate, r.revoked = tc.status, tc.signer, tc.nextUpdate, nil
+			if tc.revoked {
+				r.revoked = []*big.Int{tc.cert.SerialNumber}
+			}
+
+			result := checker.Check(tc.cert, tc.issuer, tc.stapled, time.Now())
+			if result.Status != tc.wantStatus {
+				t.Fatalf("Expected status %s, got %s (%s)", tc.wantStatus, result.Status, result.Message)
+			}
+			if result.Source != tc.wantSource {
+				t.Errorf("Expected source %q, got %q", tc.wantSource, result.Source)
+			}
+			if tc.wantSigner != "" && (result.Signer == nil || result.Signer.Subject.CommonName != tc.wantSigner) {
+				t.Errorf("Expected signer %s, got %v", tc.wantSigner, result.Signer)
+			}
+			if tc.wantStatus == StatusRevoked && result.RevokedAt.IsZero() {
+				t.Error("Expected a revocation time")
+			}
+			if tc.wantMsg != "" && !strings.Contains(result.Message, tc.wantMsg) {
+				t.Errorf("Expected message containing %q, got %q", tc.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestCheckOCSPSignatureAlgorithm(t *testing.T) {
+	ca := issue(t, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true}, nil)
+	leaf := issue(t, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "leaf"}}, ca)
+	der, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.cert.SerialNumber,
+		ThisUpdate:   time.Now(),
+		IssuerHash:   crypto.SHA256,
+	}, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create OCSP response: %v", err)
+	}
+
+	result, err := checkOCSP(der, leaf.cert, ca.cert, time.Now())
+	if err != nil {
+		t.Fatalf("checkOCSP() error: %v", err)
+	}
+	if result.SignatureAlgorithm != x509.ECDSAWithSHA256 {
+		t.Errorf("Expected ECDSA-SHA256, got %s", result.SignatureAlgorithm)
+	}
+	if _, err := checkOCSP(der, leaf.cert, ca.cert, time.Now().Add(-time.Hour)); err == nil {
+		t.Error("Expected an error for a response from the future")
+	}
+}
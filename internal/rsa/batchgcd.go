@@ -0,0 +1,148 @@
+package rsa
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Modulus is an RSA modulus tagged with the asset it came from.
+type Modulus struct {
+	Asset string
+	N     *big.Int
+}
+
+// SharedFactor is a pair of assets whose moduli have a common prime, which
+// lets anyone factor both keys. Duplicate moduli are reported with the
+// modulus itself as the factor.
+type SharedFactor struct {
+	AssetA    string
+	AssetB    string
+	Factor    *big.Int
+	Duplicate bool
+}
+
+// BatchGCD finds every pair of moduli sharing a prime factor in roughly
+// quasi-linear time using the product and remainder trees of Heninger et
+// al., "Mining Your Ps and Qs" (USENIX Security 2012).
+func BatchGCD(moduli []Modulus) []SharedFactor {
+	var results []SharedFactor
+
+	// Identical moduli divide each other completely, so pull them out first
+	// and run the trees over one representative each.
+	groups := map[string][]Modulus{}
+	var unique []Modulus
+	for _, m := range moduli {
+		if m.N == nil || m.N.Sign() <= 0 {
+			continue
+		}
+		key := string(m.N.Bytes())
+		if len(groups[key]) == 0 {
+			unique = append(unique, m)
+		}
+		groups[key] = append(groups[key], m)
+	}
+	for _, m := range unique {
+		group := groups[string(m.N.Bytes())]
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				results = append(results, SharedFactor{
+					AssetA:    group[i].Asset,
+					AssetB:    group[j].Asset,
+					Factor:    new(big.Int).Set(m.N),
+					Duplicate: true,
+				})
+			}
+		}
+	}
+
+	if len(unique) < 2 {
+		sortFactors(results)
+		return results
+	}
+
+	values := make([]*big.Int, len(unique))
+	for i, m := range unique {
+		values[i] = m.N
+	}
+	gcds := batchGCD(values)
+
+	var weak []int
+	one := big.NewInt(1)
+	for i, g := range gcds {
+		if g.Cmp(one) != 0 {
+			weak = append(weak, i)
+		}
+	}
+
+	// Only the few weak moduli need pairwise GCDs to name their partners.
+	g := new(big.Int)
+	for a := 0; a < len(weak); a++ {
+		for b := a + 1; b < len(weak); b++ {
+			left, right := unique[weak[a]], unique[weak[b]]
+			g.GCD(nil, nil, left.N, right.N)
+			if g.Cmp(one) == 0 {
+				continue
+			}
+			for _, x := range groups[string(left.N.Bytes())] {
+				for _, y := range groups[string(right.N.Bytes())] {
+					results = append(results, SharedFactor{AssetA: x.Asset, AssetB: y.Asset, Factor: new(big.Int).Set(g)})
+				}
+			}
+		}
+	}
+
+	sortFactors(results)
+	return results
+}
+
+// sortFactors orders results by their assets.
+func sortFactors(results []SharedFactor) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].AssetA != results[j].AssetA {
+			return results[i].AssetA < results[j].AssetA
+		}
+		return results[i].AssetB < results[j].AssetB
+	})
+}
+
+// batchGCD returns gcd(n_i, prod_{j != i} n_j) for every input.
+func batchGCD(moduli []*big.Int) []*big.Int {
+	tree := productTree(moduli)
+
+	remainders := tree[len(tree)-1]
+	for level := len(tree) - 2; level >= 0; level-- {
+		nodes := tree[level]
+		next := make([]*big.Int, len(nodes))
+		for i, node := range nodes {
+			square := new(big.Int).Mul(node, node)
+			next[i] = new(big.Int).Mod(remainders[i/2], square)
+		}
+		remainders = next
+	}
+
+	gcds := make([]*big.Int, len(moduli))
+	for i, n := range moduli {
+		quotient := new(big.Int).Quo(remainders[i], n)
+		gcds[i] = quotient.GCD(nil, nil, quotient, n)
+	}
+	return gcds
+}
+
+// productTree returns the levels of the tree, leaves first and the product of
+// all moduli last.
+func productTree(leaves []*big.Int) [][]*big.Int {
+	tree := [][]*big.Int{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([]*big.Int, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next[i/2] = new(big.Int).Mul(level[i], level[i+1])
+			} else {
+				next[i/2] = level[i]
+			}
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree
+}
@@ -0,0 +1,105 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestBatchGCD(t *testing.T) {
+	primes := make([]*big.Int, 7)
+	for i := range primes {
+		p, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatalf("Failed to generate prime: %v", err)
+		}
+		primes[i] = p
+	}
+	mul := func(a, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }
+
+	moduli := []Modulus{
+		{Asset: "a.pem", N: mul(primes[0], primes[1])},
+		{Asset: "b.pem", N: mul(primes[2], primes[3])},
+		{Asset: "c.pem", N: mul(primes[0], primes[4])},
+		{Asset: "d.pem", N: mul(primes[5], primes[6])},
+		{Asset: "e.pem", N: mul(primes[5], primes[6])},
+		{Asset: "f.pem", N: nil},
+	}
+
+	results := BatchGCD(moduli)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+
+	shared := results[0]
+	if shared.AssetA != "a.pem" || shared.AssetB != "c.pem" || shared.Duplicate {
+		t.Errorf("Expected a.pem and c.pem to share a prime, got %+v", shared)
+	}
+	if shared.Factor.Cmp(primes[0]) != 0 {
+		t.Errorf("Expected shared factor to be the common prime")
+	}
+
+	duplicate := results[1]
+	if duplicate.AssetA != "d.pem" || duplicate.AssetB != "e.pem" || !duplicate.Duplicate {
+		t.Errorf("Expected d.pem and e.pem to be duplicates, got %+v", duplicate)
+	}
+}
+
+func TestBatchGCDNoSharedFactors(t *testing.T) {
+	var moduli []Modulus
+	for _, asset := range []string{"a", "b", "c"} {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		moduli = append(moduli, Modulus{Asset: asset, N: privateKey.N})
+	}
+
+	if results := BatchGCD(moduli); len(results) != 0 {
+		t.Errorf("Expected no shared factors, got %+v", results)
+	}
+	if results := BatchGCD(moduli[:1]); len(results) != 0 {
+		t.Errorf("Expected no results for a single modulus, got %+v", results)
+	}
+}
+
+func TestBatchGCDDuplicatesOnlySorted(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	var moduli []Modulus
+	for _, asset := range []string{"c", "b", "a"} {
+		moduli = append(moduli, Modulus{Asset: asset, N: privateKey.N})
+	}
+
+	results := BatchGCD(moduli)
+	var got []string
+	for _, result := range results {
+		if !result.Duplicate {
+			t.Errorf("Expected only duplicates, got %+v", result)
+		}
+		got = append(got, result.AssetA+result.AssetB)
+	}
+	if strings.Join(got, ",") != "ba,ca,cb" {
+		t.Errorf("Expected pairs sorted by asset, got %v", got)
+	}
+}
+
+func TestProductTree(t *testing.T) {
+	leaves := []*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(5), big.NewInt(7), big.NewInt(11)}
+	tree := productTree(leaves)
+	if got := tree[len(tree)-1][0]; got.Cmp(big.NewInt(2310)) != 0 {
+		t.Errorf("Expected root 2310, got %s", got)
+	}
+
+	gcds := batchGCD([]*big.Int{big.NewInt(6), big.NewInt(35), big.NewInt(15), big.NewInt(77)})
+	want := []int64{3, 35, 15, 7}
+	for i, g := range gcds {
+		if g.Int64() != want[i] {
+			t.Errorf("gcd %d: expected %d, got %s", i, want[i], g)
+		}
+	}
+}
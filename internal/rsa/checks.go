@@ -0,0 +1,187 @@
+package rsa
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+const (
+	// minPublicExponent is the smallest exponent allowed by NIST SP 800-56B
+	// and FIPS 186-5.
+	minPublicExponent = 65537
+	smallPrimeBound   = 1 << 16
+	fermatRounds      = 100
+)
+
+var standardModulusSizes = map[int]bool{
+	1024: true, 1536: true, 2048: true, 3072: true, 4096: true,
+	6144: true, 7680: true, 8192: true, 15360: true, 16384: true,
+}
+
+var (
+	smallPrimesOnce    sync.Once
+	smallPrimes        []int64
+	smallPrimesProduct *big.Int
+)
+
+func loadSmallPrimes() {
+	smallPrimesOnce.Do(func() {
+		composite := make([]bool, smallPrimeBound)
+		smallPrimesProduct = big.NewInt(1)
+		for i := 2; i < smallPrimeBound; i++ {
+			if composite[i] {
+				continue
+			}
+			smallPrimes = append(smallPrimes, int64(i))
+			smallPrimesProduct.Mul(smallPrimesProduct, big.NewInt(int64(i)))
+			for j := i * i; j < smallPrimeBound; j += i {
+				composite[j] = true
+			}
+		}
+	})
+}
+
+func (r *RSAKey) publicKey() *rsa.PublicKey {
+	if r.cert != nil {
+		if rsaPub, ok := r.cert.PublicKey.(*rsa.PublicKey); ok {
+			return rsaPub
+		}
+	}
+	return r.rsaPub
+}
+
+// Modulus returns the RSA modulus of the key, or nil if it has none.
+func (r *RSAKey) Modulus() *big.Int {
+	pub := r.publicKey()
+	if pub == nil {
+		return nil
+	}
+	return pub.N
+}
+
+// Check inspects the public exponent and modulus for weaknesses that make the
+// key unsafe regardless of its length. Each failed check is its own finding.
+func (r *RSAKey) Check() []types.Finding {
+	pub := r.publicKey()
+	if pub == nil || pub.N == nil {
+		return nil
+	}
+
+	var findings []types.Finding
+	findings = append(findings, checkExponent(pub.E)...)
+	findings = append(findings, checkModulusSize(pub.N)...)
+	if f := checkSmallFactors(pub.N); f != nil {
+		findings = append(findings, *f)
+	}
+	if f := checkFermat(pub.N); f != nil {
+		findings = append(findings, *f)
+	}
+	if f := checkROCA(pub.N); f != nil {
+		findings = append(findings, *f)
+	}
+	return findings
+}
+
+func checkExponent(e int) []types.Finding {
+	switch {
+	case e == 1:
+		return []types.Finding{{
+			Check:    "rsa-exponent",
+			Severity: types.SeverityCritical,
+			Message:  "Public exponent is 1, so encryption is the identity function",
+		}}
+	case e%2 == 0:
+		return []types.Finding{{
+			Check:    "rsa-exponent",
+			Severity: types.SeverityFailed,
+			Message:  fmt.Sprintf("Public exponent %d is even and cannot be valid", e),
+		}}
+	case e < minPublicExponent:
+		return []types.Finding{{
+			Check:    "rsa-exponent",
+			Severity: types.SeverityFailed,
+			Message:  fmt.Sprintf("Public exponent %d is below %d", e, minPublicExponent),
+		}}
+	}
+	return nil
+}
+
+func checkModulusSize(n *big.Int) []types.Finding {
+	bits := n.BitLen()
+	switch {
+	case bits%8 != 0:
+		return []types.Finding{{
+			Check:    "rsa-modulus-size",
+			Severity: types.SeverityWarning,
+			Message:  fmt.Sprintf("Modulus length %d is not a multiple of 8", bits),
+		}}
+	case !standardModulusSizes[bits]:
+		return []types.Finding{{
+			Check:    "rsa-modulus-size",
+			Severity: types.SeverityWarning,
+			Message:  fmt.Sprintf("Modulus length %d is not a standard RSA key size", bits),
+		}}
+	}
+	return nil
+}
+
+// checkSmallFactors takes a single GCD against the product of all primes
+// below smallPrimeBound and only trial-divides when that finds a factor.
+func checkSmallFactors(n *big.Int) *types.Finding {
+	loadSmallPrimes()
+
+	gcd := new(big.Int).GCD(nil, nil, n, smallPrimesProduct)
+	if gcd.Cmp(big.NewInt(1)) == 0 {
+		return nil
+	}
+
+	mod := new(big.Int)
+	for _, p := range smallPrimes {
+		if mod.Mod(n, big.NewInt(p)).Sign() == 0 {
+			return &types.Finding{
+				Check:    "rsa-small-factor",
+				Severity: types.SeverityCritical,
+				Message:  fmt.Sprintf("Modulus is divisible by the small prime %d", p),
+			}
+		}
+	}
+	return nil
+}
+
+// checkFermat runs a bounded Fermat factorisation, which succeeds quickly when
+// the two primes share most of their high-order bits.
+func checkFermat(n *big.Int) *types.Finding {
+	if n.Sign() <= 0 || n.Bit(0) == 0 {
+		return nil
+	}
+
+	a := new(big.Int).Sqrt(n)
+	if new(big.Int).Mul(a, a).Cmp(n) < 0 {
+		a.Add(a, big.NewInt(1))
+	}
+
+	b2 := new(big.Int)
+	b := new(big.Int)
+	for i := 0; i < fermatRounds; i++ {
+		b2.Mul(a, a)
+		b2.Sub(b2, n)
+		b.Sqrt(b2)
+		if new(big.Int).Mul(b, b).Cmp(b2) == 0 {
+			p := new(big.Int).Sub(a, b)
+			if p.Cmp(big.NewInt(1)) > 0 {
+				return &types.Finding{
+					Check:    "rsa-fermat",
+					Severity: types.SeverityCritical,
+					Message:  fmt.Sprintf("Modulus was factored by Fermat's method after %d round(s); its primes are too close together", i+1),
+				}
+			}
+			return nil
+		}
+		a.Add(a, big.NewInt(1))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	goodKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	goodN := goodKey.N
+
+	p, err := rand.Prime(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate prime: %v", err)
+	}
+	q := nextPrime(new(big.Int).Add(p, big.NewInt(2)))
+	closePrimesN := new(big.Int).Mul(p, q)
+
+	smallFactorN := new(big.Int).Mul(big.NewInt(65521), nextPrime(new(big.Int).Lsh(big.NewInt(1), 2031)))
+
+	oddSizeN, err := rand.Prime(rand.Reader, 2045)
+	if err != nil {
+		t.Fatalf("Failed to generate prime: %v", err)
+	}
+	oddSizeN.Mul(oddSizeN, big.NewInt(3))
+
+	testCases := []struct {
+		name       string
+		n          *big.Int
+		e          int
+		wantChecks map[string]string
+		wantSecure bool
+	}{
+		{
+			name:       "GeneratedKey",
+			n:          goodN,
+			e:          65537,
+			wantChecks: map[string]string{},
+			wantSecure: true,
+		},
+		{
+			name:       "ExponentOne",
+			n:          goodN,
+			e:          1,
+			wantChecks: map[string]string{"rsa-exponent": "Critical"},
+		},
+		{
+			name:       "ExponentThree",
+			n:          goodN,
+			e:          3,
+			wantChecks: map[string]string{"rsa-exponent": "Failed"},
+		},
+		{
+			name:       "EvenExponent",
+			n:          goodN,
+			e:          65536,
+			wantChecks: map[string]string{"rsa-exponent": "Failed"},
+		},
+		{
+			name:       "ClosePrimes",
+			n:          closePrimesN,
+			e:          65537,
+			wantChecks: map[string]string{"rsa-fermat": "Critical"},
+		},
+		{
+			name: "SmallFactor",
+			n:    smallFactorN,
+			e:    65537,
+			wantChecks: map[string]string{
+				"rsa-small-factor": "Critical",
+				"rsa-modulus-size": "Warning",
+			},
+		},
+		{
+			name: "OddModulusSize",
+			n:    oddSizeN,
+			e:    65537,
+			wantChecks: map[string]string{
+				"rsa-small-factor": "Critical",
+				"rsa-modulus-size": "Warning",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := NewRSAKey(encodePublicKey(t, &rsa.PublicKey{N: tc.n, E: tc.e}))
+			if err != nil {
+				t.Fatalf("Failed to create RSAKey: %v", err)
+			}
+
+			got := map[string]string{}
+			for _, finding := range key.Check() {
+				got[finding.Check] = finding.Severity
+			}
+			if len(got) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %v", tc.wantChecks, got)
+			}
+			for check, severity := range tc.wantChecks {
+				if got[check] != severity {
+					t.Errorf("Expected %s finding with severity %s, got %q", check, severity, got[check])
+				}
+			}
+			if secure := key.IsSecure(0); secure != tc.wantSecure {
+				t.Errorf("Expected IsSecure(0) = %v, got %v", tc.wantSecure, secure)
+			}
+		})
+	}
+}
+
+func nextPrime(n *big.Int) *big.Int {
+	p := new(big.Int).Set(n)
+	if p.Bit(0) == 0 {
+		p.Add(p, big.NewInt(1))
+	}
+	for !p.ProbablyPrime(20) {
+		p.Add(p, big.NewInt(2))
+	}
+	return p
+}
+
+func encodePublicKey(t *testing.T, pub *rsa.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: der})
+}
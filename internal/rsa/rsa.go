@@ -90,10 +90,36 @@ func (r *RSAKey) GetLength() int {
 	return 0
 }
 
-// TODO Better Implementation
+// IsSecure requires the modulus to meet the threshold and every parameter
+// check in Check to pass; warnings alone do not make a key insecure.
 func (r *RSAKey) IsSecure(threshold int) bool {
 	length := r.GetLength()
-	return length >= threshold
+	if length < threshold {
+		return false
+	}
+	for _, finding := range r.Check() {
+		if finding.Severity != types.SeverityWarning {
+			return false
+		}
+	}
+	return true
+}
+
+// Certificate returns the certificate the key was read from, if any.
+func (r *RSAKey) Certificate() *x509.Certificate {
+	return r.cert
+}
+
+// SPKI returns the DER-encoded SubjectPublicKeyInfo of the key.
+func (r *RSAKey) SPKI() ([]byte, error) {
+	if r.cert != nil {
+		return r.cert.RawSubjectPublicKeyInfo, nil
+	}
+	pub := r.publicKey()
+	if pub == nil {
+		return nil, errors.New("key has no public key")
+	}
+	return x509.MarshalPKIXPublicKey(pub)
 }
 
 func (r *RSAKey) AdjustForYear(year int) int {
@@ -111,4 +137,9 @@ func (r *RSAKey) GetAlgorithm() string {
 	return "RSA"
 }
 
-var _ types.KeyLengthEvaluator = (*RSAKey)(nil)
+var (
+	_ types.KeyLengthEvaluator = (*RSAKey)(nil)
+	_ types.SanityChecker      = (*RSAKey)(nil)
+	_ types.SPKIEncoder        = (*RSAKey)(nil)
+	_ types.CertificateHolder  = (*RSAKey)(nil)
+)
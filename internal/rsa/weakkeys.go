@@ -0,0 +1,147 @@
+package rsa
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+// rocaPrimes are the small primes used by the ROCA fingerprint (Nemec et al.,
+// CCS 2017). Infineon's generator produces primes of the form k*M + 65537^a
+// mod M, so a vulnerable modulus lies in the subgroup generated by 65537
+// modulo every one of them.
+var rocaPrimes = []int64{
+	3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71, 73,
+	79, 83, 89, 97, 101, 103, 107, 109, 113, 127, 131, 137, 139, 149, 151, 157,
+	163, 167,
+}
+
+const rocaGenerator = 65537
+
+var (
+	rocaOnce      sync.Once
+	rocaSubgroups []map[int64]bool
+)
+
+func loadROCASubgroups() {
+	rocaOnce.Do(func() {
+		rocaSubgroups = make([]map[int64]bool, len(rocaPrimes))
+		for i, p := range rocaPrimes {
+			subgroup := map[int64]bool{}
+			g := int64(rocaGenerator) % p
+			for x := int64(1); !subgroup[x]; x = x * g % p {
+				subgroup[x] = true
+			}
+			rocaSubgroups[i] = subgroup
+		}
+	})
+}
+
+// isROCAVulnerable reports whether n carries the ROCA fingerprint.
+func isROCAVulnerable(n *big.Int) bool {
+	loadROCASubgroups()
+
+	mod := new(big.Int)
+	for i, p := range rocaPrimes {
+		residue := mod.Mod(n, big.NewInt(p)).Int64()
+		if !rocaSubgroups[i][residue] {
+			return false
+		}
+	}
+	return true
+}
+
+func checkROCA(n *big.Int) *types.Finding {
+	if !isROCAVulnerable(n) {
+		return nil
+	}
+	return &types.Finding{
+		Check:    "rsa-roca",
+		Severity: types.SeverityCritical,
+		Message:  "Modulus has the ROCA fingerprint of Infineon's vulnerable key generator (CVE-2017-15361)",
+	}
+}
+
+// DebianBlocklist holds fingerprints of the keys produced by the Debian
+// OpenSSL random number generator bug (CVE-2008-0166).
+type DebianBlocklist struct {
+	fingerprints map[string]string
+}
+
+// LoadDebianBlocklist reads blocklists in the openssl-blacklist format: one
+// hex SHA-1 of "Modulus=<HEX>\n" per line, usually truncated to its last 20
+// digits, with '#' starting a comment.
+func LoadDebianBlocklist(paths ...string) (*DebianBlocklist, error) {
+	b := &DebianBlocklist{fingerprints: map[string]string{}}
+	for _, path := range paths {
+		if err := b.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *DebianBlocklist) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.New("failed to open Debian blocklist: " + err.Error())
+	}
+	defer f.Close()
+
+	source := filepath.Base(path)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.ToLower(line)
+		if _, err := hex.DecodeString(line); err != nil || len(line) < 20 || len(line) > 40 {
+			return errors.New("invalid Debian blocklist entry in " + path + ": " + line)
+		}
+		b.fingerprints[line[len(line)-20:]] = source
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.New("failed to read Debian blocklist: " + err.Error())
+	}
+	return nil
+}
+
+// Len returns the number of fingerprints loaded.
+func (b *DebianBlocklist) Len() int {
+	return len(b.fingerprints)
+}
+
+// Check reports a critical finding when the key's modulus is blocklisted. The
+// finding does not depend on any threshold.
+func (b *DebianBlocklist) Check(key *RSAKey) *types.Finding {
+	pub := key.publicKey()
+	if b == nil || pub == nil || pub.N == nil {
+		return nil
+	}
+
+	source, ok := b.fingerprints[debianFingerprint(pub.N)]
+	if !ok {
+		return nil
+	}
+	return &types.Finding{
+		Check:    "rsa-debian-weak-key",
+		Severity: types.SeverityCritical,
+		Message:  "Modulus is in the Debian OpenSSL weak-key blocklist " + source + " (CVE-2008-0166)",
+	}
+}
+
+func debianFingerprint(n *big.Int) string {
+	// The blocklist format is defined in terms of SHA-1 of openssl's output.
+	sum := sha1.Sum([]byte("Modulus=" + strings.ToUpper(n.Text(16)) + "\n"))
+	digest := hex.EncodeToString(sum[:])
+	return digest[len(digest)-20:]
+}
@@ -0,0 +1,116 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestROCA(t *testing.T) {
+	t.Run("Fingerprinted", func(t *testing.T) {
+		// Any modulus congruent to a power of 65537 modulo the product of the
+		// ROCA primes carries the fingerprint.
+		m := big.NewInt(1)
+		for _, p := range rocaPrimes {
+			m.Mul(m, big.NewInt(p))
+		}
+		n := new(big.Int).Exp(big.NewInt(rocaGenerator), big.NewInt(1234), m)
+		k, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 1800))
+		if err != nil {
+			t.Fatalf("Failed to generate multiplier: %v", err)
+		}
+		n.Add(n, k.Mul(k, m))
+
+		if !isROCAVulnerable(n) {
+			t.Fatal("Expected fingerprinted modulus to be detected")
+		}
+		key, err := NewRSAKey(encodePublicKey(t, &rsa.PublicKey{N: n, E: 65537}))
+		if err != nil {
+			t.Fatalf("Failed to create RSAKey: %v", err)
+		}
+		found := false
+		for _, finding := range key.Check() {
+			if finding.Check == "rsa-roca" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected rsa-roca finding, got %+v", key.Check())
+		}
+	})
+
+	t.Run("GeneratedKey", func(t *testing.T) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		if isROCAVulnerable(privateKey.N) {
+			t.Error("Generated key should not carry the ROCA fingerprint")
+		}
+	})
+}
+
+func TestDebianBlocklist(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blacklist.RSA-2048")
+	content := "# Debian weak keys\n" + debianFingerprint(privateKey.N) + "\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+
+	blocklist, err := LoadDebianBlocklist(path)
+	if err != nil {
+		t.Fatalf("LoadDebianBlocklist() error: %v", err)
+	}
+	if blocklist.Len() != 1 {
+		t.Fatalf("Expected 1 fingerprint, got %d", blocklist.Len())
+	}
+
+	listed, err := NewRSAKey(encodePublicKey(t, &privateKey.PublicKey))
+	if err != nil {
+		t.Fatalf("Failed to create RSAKey: %v", err)
+	}
+	finding := blocklist.Check(listed)
+	if finding == nil || finding.Severity != "Critical" {
+		t.Errorf("Expected critical finding for blocklisted key, got %+v", finding)
+	}
+
+	unlisted, err := NewRSAKey(encodePublicKey(t, &otherKey.PublicKey))
+	if err != nil {
+		t.Fatalf("Failed to create RSAKey: %v", err)
+	}
+	if finding := blocklist.Check(unlisted); finding != nil {
+		t.Errorf("Expected no finding for unlisted key, got %+v", finding)
+	}
+
+	t.Run("InvalidEntry", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad")
+		if err := os.WriteFile(bad, []byte("not-a-fingerprint\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write blocklist: %v", err)
+		}
+		if _, err := LoadDebianBlocklist(bad); err == nil {
+			t.Error("Expected error for invalid entry")
+		}
+	})
+}
+
+func TestDebianFingerprint(t *testing.T) {
+	// openssl rsa -noout -modulus prints "Modulus=" followed by upper-case
+	// hex; the blocklist stores the last 20 hex digits of its SHA-1.
+	n, _ := new(big.Int).SetString("C0FFEE", 16)
+	if got, want := debianFingerprint(n), "6149ed99475722f13e18"; got != want {
+		t.Errorf("debianFingerprint() = %s, want %s", got, want)
+	}
+}
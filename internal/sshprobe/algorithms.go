@@ -0,0 +1,152 @@
+package sshprobe
+
+import "strings"
+
+// KeyExchange is a catalogued SSH key exchange method. Bits is the size the
+// standard's thresholds are compared against, as for TLS groups: the prime
+// size for finite-field groups and the field size for elliptic curves, with
+// hybrid methods sized by their classical component. Group exchange methods
+// have no fixed size; the prime the server picks is probed separately.
+type KeyExchange struct {
+	Name string
+	Kind string
+	Bits int
+	Hash string
+}
+
+const (
+	KindDH     = "DH"
+	KindGEX    = "DH-GEX"
+	KindECDH   = "ECDH"
+	KindHybrid = "Hybrid"
+)
+
+// KeyExchanges is the catalog of key exchange methods recognised.
+var KeyExchanges = []KeyExchange{
+	{"diffie-hellman-group1-sha1", KindDH, 1024, "SHA-1"},
+	{"diffie-hellman-group14-sha1", KindDH, 2048, "SHA-1"},
+	{"diffie-hellman-group14-sha256", KindDH, 2048, "SHA-256"},
+	{"diffie-hellman-group15-sha512", KindDH, 3072, "SHA-512"},
+	{"diffie-hellman-group16-sha512", KindDH, 4096, "SHA-512"},
+	{"diffie-hellman-group17-sha512", KindDH, 6144, "SHA-512"},
+	{"diffie-hellman-group18-sha512", KindDH, 8192, "SHA-512"},
+	{"diffie-hellman-group-exchange-sha1", KindGEX, 0, "SHA-1"},
+	{"diffie-hellman-group-exchange-sha256", KindGEX, 0, "SHA-256"},
+	{"ecdh-sha2-nistp256", KindECDH, 256, "SHA-256"},
+	{"ecdh-sha2-nistp384", KindECDH, 384, "SHA-384"},
+	{"ecdh-sha2-nistp521", KindECDH, 521, "SHA-512"},
+	{"curve25519-sha256", KindECDH, 256, "SHA-256"},
+	{"curve25519-sha256@libssh.org", KindECDH, 256, "SHA-256"},
+	{"sntrup761x25519-sha512", KindHybrid, 256, "SHA-512"},
+	{"sntrup761x25519-sha512@openssh.com", KindHybrid, 256, "SHA-512"},
+	{"mlkem768x25519-sha256", KindHybrid, 256, "SHA-256"},
+}
+
+// Cipher is a catalogued SSH encryption algorithm. Mode is CTR, CBC, GCM,
+// AEAD for ChaCha20-Poly1305, or Stream; BlockBits is zero for stream
+// ciphers.
+type Cipher struct {
+	Name      string
+	Cipher    string
+	Bits      int
+	Mode      string
+	BlockBits int
+}
+
+// Ciphers is the catalog of encryption algorithms recognised.
+var Ciphers = []Cipher{
+	{"chacha20-poly1305@openssh.com", "ChaCha20", 256, "AEAD", 0},
+	{"aes128-gcm@openssh.com", "AES", 128, "GCM", 128},
+	{"aes256-gcm@openssh.com", "AES", 256, "GCM", 128},
+	{"aes128-ctr", "AES", 128, "CTR", 128},
+	{"aes192-ctr", "AES", 192, "CTR", 128},
+	{"aes256-ctr", "AES", 256, "CTR", 128},
+	{"aes128-cbc", "AES", 128, "CBC", 128},
+	{"aes192-cbc", "AES", 192, "CBC", 128},
+	{"aes256-cbc", "AES", 256, "CBC", 128},
+	{"rijndael-cbc@lysator.liu.se", "AES", 256, "CBC", 128},
+	{"3des-cbc", "3DES", 112, "CBC", 64},
+	{"3des-ctr", "3DES", 112, "CTR", 64},
+	{"blowfish-cbc", "Blowfish", 128, "CBC", 64},
+	{"cast128-cbc", "CAST-128", 128, "CBC", 64},
+	{"arcfour", "RC4", 128, "Stream", 0},
+	{"arcfour128", "RC4", 128, "Stream", 0},
+	{"arcfour256", "RC4", 256, "Stream", 0},
+	{"none", "NULL", 0, "Stream", 0},
+}
+
+// AEAD reports whether the cipher authenticates its own ciphertext, in which
+// case the negotiated MAC is not used.
+func (c Cipher) AEAD() bool {
+	return c.Mode == "GCM" || c.Mode == "AEAD"
+}
+
+// MAC is a catalogued SSH message authentication algorithm. TagBits is the
+// length of the transmitted tag, and ETM marks encrypt-then-MAC variants.
+type MAC struct {
+	Name    string
+	Hash    string
+	TagBits int
+	ETM     bool
+}
+
+// MACs is the catalog of MAC algorithms recognised.
+var MACs = []MAC{
+	{"hmac-sha2-256-etm@openssh.com", "SHA-256", 256, true},
+	{"hmac-sha2-512-etm@openssh.com", "SHA-512", 512, true},
+	{"umac-128-etm@openssh.com", "UMAC", 128, true},
+	{"umac-64-etm@openssh.com", "UMAC", 64, true},
+	{"hmac-sha1-etm@openssh.com", "SHA-1", 160, true},
+	{"hmac-sha1-96-etm@openssh.com", "SHA-1", 96, true},
+	{"hmac-md5-etm@openssh.com", "MD5", 128, true},
+	{"hmac-md5-96-etm@openssh.com", "MD5", 96, true},
+	{"hmac-sha2-256", "SHA-256", 256, false},
+	{"hmac-sha2-512", "SHA-512", 512, false},
+	{"umac-128@openssh.com", "UMAC", 128, false},
+	{"umac-64@openssh.com", "UMAC", 64, false},
+	{"hmac-sha1", "SHA-1", 160, false},
+	{"hmac-sha1-96", "SHA-1", 96, false},
+	{"hmac-md5", "MD5", 128, false},
+	{"hmac-md5-96", "MD5", 96, false},
+	{"hmac-ripemd160", "RIPEMD-160", 160, false},
+	{"hmac-ripemd160@openssh.com", "RIPEMD-160", 160, false},
+	{"none", "NULL", 0, false},
+}
+
+// KeyExchangeByName looks up a key exchange method in the catalog.
+func KeyExchangeByName(name string) (KeyExchange, bool) {
+	for _, k := range KeyExchanges {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return KeyExchange{}, false
+}
+
+// CipherByName looks up an encryption algorithm in the catalog.
+func CipherByName(name string) (Cipher, bool) {
+	for _, c := range Ciphers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Cipher{}, false
+}
+
+// MACByName looks up a MAC algorithm in the catalog.
+func MACByName(name string) (MAC, bool) {
+	for _, m := range MACs {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return MAC{}, false
+}
+
+const kexStrictServer = "kex-strict-s-v00@openssh.com"
+
+// isKexExtension reports whether a name in the key exchange list signals a
+// protocol extension, such as strict key exchange, rather than a method.
+func isKexExtension(name string) bool {
+	return strings.HasPrefix(name, "kex-strict-") || strings.HasPrefix(name, "ext-info-")
+}
@@ -0,0 +1,410 @@
+package sshprobe
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/ssh"
+)
+
+const clientVersion = "SSH-2.0-keylength-check"
+
+// maxPacket is the largest unencrypted packet accepted (RFC 4253, 6.1).
+const maxPacket = 35000
+
+const (
+	msgIgnore          = 2
+	msgDebug           = 4
+	msgKexInit         = 20
+	msgKexDHGEXGroup   = 31
+	msgKexDHGEXRequest = 34
+)
+
+// Prober speaks the SSH transport protocol up to the key exchange, where the
+// algorithms are still sent in the clear, so it can list what a server offers
+// without authenticating.
+type Prober struct {
+	Address string
+	Timeout time.Duration
+	// Dial, if set, opens each connection instead of net.DialTimeout.
+	Dial func(network, address string) (net.Conn, error)
+}
+
+// Algorithms are the server's identification string and the algorithms its
+// KEXINIT offers, in its order of preference. Ciphers and MACs merge the two
+// directions. StrictKex is set when the server implements the strict key
+// exchange countermeasure to Terrapin (CVE-2023-48795).
+type Algorithms struct {
+	Banner       string
+	KeyExchanges []string
+	HostKeys     []string
+	Ciphers      []string
+	MACs         []string
+	StrictKex    bool
+}
+
+// HostKey is a key the server proved possession of, with the host key
+// algorithms it was offered under. Certificate is set when the key was
+// presented in an OpenSSH host certificate.
+type HostKey struct {
+	Key         ssh.PublicKey
+	Certificate *ssh.Certificate
+	Algorithms  []string
+}
+
+// Skipped is a host key algorithm no key was collected under. Err is nil
+// when crypto/ssh cannot negotiate the algorithm, and otherwise why the key
+// exchange failed.
+type Skipped struct {
+	Algorithm string
+	Err       error
+}
+
+// Algorithms reads the server's KEXINIT.
+func (p *Prober) Algorithms() (*Algorithms, error) {
+	conn, r, banner, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	kexInit, err := readKexInit(r)
+	if err != nil {
+		return nil, err
+	}
+	algorithms := &Algorithms{
+		Banner:   banner,
+		HostKeys: kexInit[1],
+		Ciphers:  merge(kexInit[2], kexInit[3]),
+		MACs:     merge(kexInit[4], kexInit[5]),
+	}
+	for _, name := range kexInit[0] {
+		switch {
+		case name == kexStrictServer:
+			algorithms.StrictKex = true
+		case !isKexExtension(name):
+			algorithms.KeyExchanges = append(algorithms.KeyExchanges, name)
+		}
+	}
+	return algorithms, nil
+}
+
+// GroupExchange asks the server for a group with the group exchange method
+// name (RFC 4419), requesting 1024 bits so the server answers with the
+// smallest prime it is willing to use, and returns that prime.
+func (p *Prober) GroupExchange(algorithms *Algorithms, name string) (*big.Int, error) {
+	conn, r, _, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := readKexInit(r); err != nil {
+		return nil, err
+	}
+	lists := [][]string{{name}, algorithms.HostKeys, algorithms.Ciphers, algorithms.Ciphers, algorithms.MACs, algorithms.MACs, {"none"}, {"none"}, nil, nil}
+	if err := writePacket(conn, marshalKexInit(lists)); err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(msgKexDHGEXRequest)
+	b.AddUint32(1024)
+	b.AddUint32(1024)
+	b.AddUint32(8192)
+	if err := writePacket(conn, b.BytesOrPanic()); err != nil {
+		return nil, err
+	}
+
+	payload, err := readMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	s := cryptobyte.String(payload)
+	var msgType uint8
+	var prime []byte
+	if !s.ReadUint8(&msgType) || msgType != msgKexDHGEXGroup || !readString(&s, &prime) {
+		return nil, fmt.Errorf("unexpected reply to group exchange request (message %d)", msgType)
+	}
+	return new(big.Int).SetBytes(prime), nil
+}
+
+// errCaptured aborts a handshake once the host key has been seen.
+var errCaptured = errors.New("host key captured")
+
+// hostKeyAlgorithms are the host key algorithms crypto/ssh can negotiate.
+var hostKeyAlgorithms = []string{
+	ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSAv01, ssh.CertAlgoDSAv01,
+	ssh.CertAlgoECDSA256v01, ssh.CertAlgoECDSA384v01, ssh.CertAlgoECDSA521v01, ssh.CertAlgoED25519v01,
+	ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSA, ssh.KeyAlgoDSA,
+	ssh.KeyAlgoED25519,
+}
+
+// The key exchange methods, ciphers and MACs crypto/ssh implements, all
+// offered so a host key can be fetched from servers with weak settings too.
+var (
+	clientKeyExchanges = []string{
+		"curve25519-sha256", "curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512",
+		"diffie-hellman-group-exchange-sha256", "diffie-hellman-group14-sha1",
+		"diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1",
+	}
+	clientCiphers = []string{
+		"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr", "aes128-cbc", "3des-cbc",
+		"arcfour256", "arcfour128", "arcfour",
+	}
+	clientMACs = []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com",
+		"hmac-sha2-256", "hmac-sha2-512", "hmac-sha1", "hmac-sha1-96",
+	}
+)
+
+// HostKeys completes a key exchange for each host key algorithm that
+// crypto/ssh can negotiate, offering only that algorithm, and collects the
+// key the server signed the exchange with. A key offered under several
+// algorithms, such as an RSA key under rsa-sha2-256 and rsa-sha2-512, is
+// returned once. The algorithms that could not be probed, or whose key
+// exchange failed, are returned as skipped; servers often advertise an
+// algorithm they then refuse.
+func (p *Prober) HostKeys(algorithms []string) (keys []HostKey, skipped []Skipped) {
+	seen := map[string]int{}
+	for _, algorithm := range algorithms {
+		if !contains(hostKeyAlgorithms, algorithm) {
+			skipped = append(skipped, Skipped{Algorithm: algorithm})
+			continue
+		}
+		key, err := p.hostKey(algorithm)
+		if err != nil {
+			skipped = append(skipped, Skipped{Algorithm: algorithm, Err: err})
+			continue
+		}
+
+		id := string(key.Marshal())
+		if i, ok := seen[id]; ok {
+			keys[i].Algorithms = append(keys[i].Algorithms, algorithm)
+			continue
+		}
+		seen[id] = len(keys)
+		hostKey := HostKey{Key: key, Algorithms: []string{algorithm}}
+		if cert, ok := key.(*ssh.Certificate); ok {
+			hostKey.Key, hostKey.Certificate = cert.Key, cert
+		}
+		keys = append(keys, hostKey)
+	}
+	return keys, skipped
+}
+
+func (p *Prober) hostKey(algorithm string) (ssh.PublicKey, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var captured ssh.PublicKey
+	config := &ssh.ClientConfig{
+		Config: ssh.Config{
+			KeyExchanges: clientKeyExchanges,
+			Ciphers:      clientCiphers,
+			MACs:         clientMACs,
+		},
+		User:              "keylength-check",
+		ClientVersion:     clientVersion,
+		HostKeyAlgorithms: []string{algorithm},
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			captured = key
+			return errCaptured
+		},
+	}
+	_, _, _, err = ssh.NewClientConn(conn, p.Address, config)
+	if captured != nil {
+		return captured, nil
+	}
+	if err == nil {
+		err = errors.New("server did not present a host key")
+	}
+	return nil, err
+}
+
+func (p *Prober) timeout() time.Duration {
+	if p.Timeout == 0 {
+		return 5 * time.Second
+	}
+	return p.Timeout
+}
+
+func (p *Prober) dial() (net.Conn, error) {
+	dial := p.Dial
+	if dial == nil {
+		dial = func(network, address string) (net.Conn, error) {
+			return net.DialTimeout(network, address, p.timeout())
+		}
+	}
+	conn, err := dial("tcp", p.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(p.timeout())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connect opens a connection and exchanges identification strings. Servers
+// may send other lines before theirs (RFC 4253, 4.2).
+func (p *Prober) connect() (net.Conn, *bufio.Reader, string, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if _, err := io.WriteString(conn, clientVersion+"\r\n"); err != nil {
+		conn.Close()
+		return nil, nil, "", err
+	}
+
+	r := bufio.NewReader(conn)
+	for i := 0; i < 50; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return nil, nil, "", fmt.Errorf("reading identification string: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			if !strings.HasPrefix(line, "SSH-2.0-") && !strings.HasPrefix(line, "SSH-1.99-") {
+				conn.Close()
+				return nil, nil, "", fmt.Errorf("server does not speak SSH 2: %s", line)
+			}
+			return conn, r, line, nil
+		}
+	}
+	conn.Close()
+	return nil, nil, "", errors.New("no SSH identification string received")
+}
+
+// readKexInit reads the server's KEXINIT and returns its ten name-lists.
+func readKexInit(r io.Reader) ([][]string, error) {
+	payload, err := readMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	s := cryptobyte.String(payload)
+	var msgType uint8
+	if !s.ReadUint8(&msgType) || msgType != msgKexInit || !s.Skip(16) {
+		return nil, fmt.Errorf("expected KEXINIT, got message %d", msgType)
+	}
+	lists := make([][]string, 10)
+	for i := range lists {
+		var list []byte
+		if !readString(&s, &list) {
+			return nil, errors.New("malformed KEXINIT")
+		}
+		if len(list) > 0 {
+			lists[i] = strings.Split(string(list), ",")
+		}
+	}
+	return lists, nil
+}
+
+func marshalKexInit(lists [][]string) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(msgKexInit)
+	cookie := make([]byte, 16)
+	rand.Read(cookie)
+	b.AddBytes(cookie)
+	for _, list := range lists {
+		b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(strings.Join(list, ",")))
+		})
+	}
+	b.AddUint8(0)  // first_kex_packet_follows
+	b.AddUint32(0) // reserved
+	return b.BytesOrPanic()
+}
+
+// readString reads an SSH string, a uint32 length followed by the bytes.
+func readString(s *cryptobyte.String, out *[]byte) bool {
+	var length uint32
+	return s.ReadUint32(&length) && s.ReadBytes(out, int(length))
+}
+
+// readMessage returns the payload of the next packet that is not an ignore
+// or debug message.
+func readMessage(r io.Reader) ([]byte, error) {
+	for {
+		payload, err := readPacket(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) == 0 {
+			return nil, errors.New("empty packet")
+		}
+		if payload[0] != msgIgnore && payload[0] != msgDebug {
+			return payload, nil
+		}
+	}
+}
+
+// readPacket reads one unencrypted binary packet (RFC 4253, 6).
+func readPacket(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	padding := uint32(header[4])
+	if length < 5 || length > maxPacket || padding+1 > length {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body[:length-1-padding], nil
+}
+
+func writePacket(w io.Writer, payload []byte) error {
+	padding := 8 - (5+len(payload))%8
+	if padding < 4 {
+		padding += 8
+	}
+	packet := make([]byte, 5+len(payload)+padding)
+	binary.BigEndian.PutUint32(packet, uint32(1+len(payload)+padding))
+	packet[4] = byte(padding)
+	copy(packet[5:], payload)
+	_, err := w.Write(packet)
+	return err
+}
+
+// merge returns a followed by the names in b that a lacks.
+func merge(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, name := range b {
+		if !contains(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
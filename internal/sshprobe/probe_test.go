@@ -0,0 +1,215 @@
+package sshprobe
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/ssh"
+)
+
+// serve accepts connections on a local listener and hands each to handle.
+func serve(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func newSigner(t *testing.T, key interface{}) ssh.Signer {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestProbeServer(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	config := &ssh.ServerConfig{
+		Config: ssh.Config{
+			KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group14-sha1"},
+			Ciphers:      []string{"aes128-gcm@openssh.com", "aes256-ctr"},
+			MACs:         []string{"hmac-sha2-256-etm@openssh.com", "hmac-sha1"},
+		},
+		NoClientAuth: true,
+	}
+	for _, key := range []interface{}{rsaKey, ecKey, edKey} {
+		config.AddHostKey(newSigner(t, key))
+	}
+	address := serve(t, func(conn net.Conn) {
+		ssh.NewServerConn(conn, config)
+	})
+
+	prober := &Prober{Address: address, Timeout: 5 * time.Second}
+	algorithms, err := prober.Algorithms()
+	if err != nil {
+		t.Fatalf("Algorithms() error: %v", err)
+	}
+	if !strings.HasPrefix(algorithms.Banner, "SSH-2.0-") {
+		t.Errorf("Expected an SSH-2.0 banner, got %q", algorithms.Banner)
+	}
+	if strings.Join(algorithms.KeyExchanges, ",") != "curve25519-sha256,diffie-hellman-group14-sha1" {
+		t.Errorf("Expected the configured key exchanges without extensions, got %v", algorithms.KeyExchanges)
+	}
+	if !algorithms.StrictKex {
+		t.Error("Expected strict key exchange to be advertised")
+	}
+	if strings.Join(algorithms.Ciphers, ",") != "aes128-gcm@openssh.com,aes256-ctr" {
+		t.Errorf("Unexpected ciphers %v", algorithms.Ciphers)
+	}
+	if strings.Join(algorithms.MACs, ",") != "hmac-sha2-256-etm@openssh.com,hmac-sha1" {
+		t.Errorf("Unexpected MACs %v", algorithms.MACs)
+	}
+
+	keys, skipped := prober.HostKeys(append(algorithms.HostKeys, "ssh-ed448"))
+	if len(skipped) != 1 || skipped[0].Algorithm != "ssh-ed448" || skipped[0].Err != nil {
+		t.Errorf("Expected ssh-ed448 to be skipped, got %v", skipped)
+	}
+	types := map[string][]string{}
+	for _, key := range keys {
+		types[key.Key.Type()] = key.Algorithms
+	}
+	if len(keys) != 3 || len(types) != 3 {
+		t.Fatalf("Expected three distinct host keys, got %d: %v", len(keys), types)
+	}
+	if rsaAlgorithms := types[ssh.KeyAlgoRSA]; len(rsaAlgorithms) < 2 || !contains(rsaAlgorithms, ssh.KeyAlgoRSASHA512) {
+		t.Errorf("Expected the RSA key under several signature algorithms, got %v", rsaAlgorithms)
+	}
+	if _, ok := types[ssh.KeyAlgoECDSA384]; !ok {
+		t.Errorf("Expected a P-384 host key, got %v", types)
+	}
+}
+
+func TestHostKeysRefusedAlgorithm(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	for _, key := range []interface{}{ecKey, edKey} {
+		config.AddHostKey(newSigner(t, key))
+	}
+	address := serve(t, func(conn net.Conn) {
+		ssh.NewServerConn(conn, config)
+	})
+
+	// The server has no RSA key, so the exchange offering only rsa-sha2-256
+	// fails, as it does on servers that advertise an algorithm they refuse.
+	prober := &Prober{Address: address, Timeout: 5 * time.Second}
+	keys, skipped := prober.HostKeys([]string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoECDSA256})
+	if len(skipped) != 1 || skipped[0].Algorithm != ssh.KeyAlgoRSASHA256 || skipped[0].Err == nil {
+		t.Errorf("Expected rsa-sha2-256 to be skipped with an error, got %v", skipped)
+	}
+	if len(keys) != 2 || keys[0].Key.Type() != ssh.KeyAlgoED25519 || keys[1].Key.Type() != ssh.KeyAlgoECDSA256 {
+		t.Errorf("Expected the Ed25519 and P-256 host keys, got %d keys", len(keys))
+	}
+}
+
+func TestGroupExchange(t *testing.T) {
+	prime, _ := new(big.Int).SetString("ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f24117c4b1fe649286651ece65381ffffffffffffffff", 16)
+
+	var request [3]uint32
+	address := serve(t, func(conn net.Conn) {
+		io.WriteString(conn, "Welcome\r\nSSH-2.0-Stand-in\r\n")
+		r := bufio.NewReader(conn)
+		if _, err := r.ReadString('\n'); err != nil {
+			return
+		}
+		lists := [][]string{{"diffie-hellman-group-exchange-sha256", "ext-info-s"}, {"ssh-ed25519"}, {"aes128-ctr"}, {"aes128-ctr"}, {"hmac-sha2-256"}, {"hmac-sha2-256"}, {"none"}, {"none"}, nil, nil}
+		writePacket(conn, marshalKexInit(lists))
+
+		if _, err := readKexInit(r); err != nil {
+			return
+		}
+		payload, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		s := cryptobyte.String(payload[1:])
+		s.ReadUint32(&request[0])
+		s.ReadUint32(&request[1])
+		s.ReadUint32(&request[2])
+
+		var b cryptobyte.Builder
+		b.AddUint8(msgKexDHGEXGroup)
+		for _, n := range []*big.Int{prime, big.NewInt(2)} {
+			b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint8(0)
+				b.AddBytes(n.Bytes())
+			})
+		}
+		writePacket(conn, b.BytesOrPanic())
+	})
+
+	prober := &Prober{Address: address, Timeout: 5 * time.Second}
+	algorithms, err := prober.Algorithms()
+	if err != nil {
+		t.Fatalf("Algorithms() error: %v", err)
+	}
+	if algorithms.Banner != "SSH-2.0-Stand-in" {
+		t.Errorf("Expected the banner after the greeting line, got %q", algorithms.Banner)
+	}
+	if algorithms.StrictKex || len(algorithms.KeyExchanges) != 1 {
+		t.Errorf("Expected one key exchange and no strict kex, got %+v", algorithms)
+	}
+
+	got, err := prober.GroupExchange(algorithms, "diffie-hellman-group-exchange-sha256")
+	if err != nil {
+		t.Fatalf("GroupExchange() error: %v", err)
+	}
+	if got.Cmp(prime) != 0 {
+		t.Errorf("Expected the server's %d-bit prime, got %d bits", prime.BitLen(), got.BitLen())
+	}
+	if request != [3]uint32{1024, 1024, 8192} {
+		t.Errorf("Expected a 1024/1024/8192 request, got %v", request)
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 3, 4, 11, 100} {
+		payload := make([]byte, size)
+		rand.Read(payload)
+		client, server := net.Pipe()
+		go func() {
+			writePacket(client, payload)
+			client.Close()
+		}()
+		got, err := readPacket(server)
+		if err != nil {
+			t.Fatalf("readPacket() error for %d bytes: %v", size, err)
+		}
+		if string(got) != string(payload) {
+			t.Errorf("Payload of %d bytes did not round-trip", size)
+		}
+		server.Close()
+	}
+}
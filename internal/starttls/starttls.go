@@ -0,0 +1,329 @@
+package starttls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+const (
+	SMTP     = "smtp"
+	IMAP     = "imap"
+	POP3     = "pop3"
+	FTP      = "ftp"
+	LDAP     = "ldap"
+	XMPP     = "xmpp"
+	Postgres = "postgres"
+)
+
+// Protocols lists the protocols Upgrade supports.
+var Protocols = []string{SMTP, IMAP, POP3, FTP, LDAP, XMPP, Postgres}
+
+var wellKnownPorts = map[string]string{
+	"21":   FTP,
+	"25":   SMTP,
+	"110":  POP3,
+	"143":  IMAP,
+	"389":  LDAP,
+	"587":  SMTP,
+	"5222": XMPP,
+	"5432": Postgres,
+}
+
+// ForPort returns the protocol upgraded with STARTTLS on a well-known port,
+// or "" for ports that speak TLS from the start.
+func ForPort(port string) string {
+	return wellKnownPorts[port]
+}
+
+// Supported reports whether protocol is one Upgrade knows.
+func Supported(protocol string) bool {
+	for _, p := range Protocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// Upgrade runs the protocol's plaintext exchange on conn until the server is
+// waiting for a TLS ClientHello. host is announced where the protocol asks
+// for it.
+func Upgrade(conn net.Conn, protocol, host string) error {
+	r := bufio.NewReader(conn)
+	var err error
+	switch protocol {
+	case SMTP:
+		err = upgradeSMTP(conn, r)
+	case IMAP:
+		err = upgradeIMAP(conn, r)
+	case POP3:
+		err = upgradePOP3(conn, r)
+	case FTP:
+		err = upgradeFTP(conn, r)
+	case LDAP:
+		err = upgradeLDAP(conn, r)
+	case XMPP:
+		err = upgradeXMPP(conn, r, host)
+	case Postgres:
+		err = upgradePostgres(conn, r)
+	default:
+		return fmt.Errorf("unsupported STARTTLS protocol %q (supported: %s)", protocol, strings.Join(Protocols, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("STARTTLS (%s): %w", protocol, err)
+	}
+	if r.Buffered() > 0 {
+		return fmt.Errorf("STARTTLS (%s): unexpected data from server before TLS handshake", protocol)
+	}
+	return nil
+}
+
+// readReply reads a reply in the multiline format shared by SMTP and FTP,
+// where "250-" continues a reply and "250 " ends it.
+func readReply(r *bufio.Reader) (string, []string, error) {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 3 {
+			return "", nil, fmt.Errorf("malformed reply %q", line)
+		}
+		lines = append(lines, line)
+		if len(line) == 3 || line[3] == ' ' {
+			return line[:3], lines, nil
+		}
+	}
+}
+
+func expectReply(r *bufio.Reader, code string) ([]string, error) {
+	got, lines, err := readReply(r)
+	if err != nil {
+		return nil, err
+	}
+	if got != code {
+		return nil, fmt.Errorf("expected %s reply, got %q", code, lines[len(lines)-1])
+	}
+	return lines, nil
+}
+
+func upgradeSMTP(w io.Writer, r *bufio.Reader) error {
+	if _, err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "EHLO keylength-check\r\n"); err != nil {
+		return err
+	}
+	lines, err := expectReply(r, "250")
+	if err != nil {
+		return err
+	}
+	advertised := false
+	for _, line := range lines[1:] {
+		// readReply accepts a bare code as the final line.
+		if len(line) > 4 {
+			advertised = advertised || strings.EqualFold(strings.TrimSpace(line[4:]), "STARTTLS")
+		}
+	}
+	if !advertised {
+		return errors.New("server does not advertise STARTTLS")
+	}
+	if _, err := io.WriteString(w, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	_, err = expectReply(r, "220")
+	return err
+}
+
+func upgradeFTP(w io.Writer, r *bufio.Reader) error {
+	if _, err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "AUTH TLS\r\n"); err != nil {
+		return err
+	}
+	_, err := expectReply(r, "234")
+	return err
+}
+
+func upgradeIMAP(w io.Writer, r *bufio.Reader) error {
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(greeting))
+	}
+	if _, err := io.WriteString(w, "a1 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "a1 ") {
+			if !strings.HasPrefix(line, "a1 OK") {
+				return fmt.Errorf("server refused STARTTLS: %q", strings.TrimSpace(line))
+			}
+			return nil
+		}
+	}
+}
+
+func upgradePOP3(w io.Writer, r *bufio.Reader) error {
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(greeting))
+	}
+	if _, err := io.WriteString(w, "STLS\r\n"); err != nil {
+		return err
+	}
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(reply, "+OK") {
+		return fmt.Errorf("server refused STLS: %q", strings.TrimSpace(reply))
+	}
+	return nil
+}
+
+// ldapStartTLS is an ExtendedRequest for the StartTLS OID 1.3.6.1.4.1.1466.20037
+// with message ID 1 (RFC 4511, Section 4.14).
+var ldapStartTLS = append([]byte{0x30, 0x1d, 0x02, 0x01, 0x01, 0x77, 0x18, 0x80, 0x16}, "1.3.6.1.4.1.1466.20037"...)
+
+// ldapExtendedResponse is the [APPLICATION 24] tag of an ExtendedResponse.
+const ldapExtendedResponse = cbasn1.Tag(0x40 | 0x20 | 24)
+
+func upgradeLDAP(w io.Writer, r *bufio.Reader) error {
+	if _, err := w.Write(ldapStartTLS); err != nil {
+		return err
+	}
+	message, err := readBER(r)
+	if err != nil {
+		return err
+	}
+
+	s := cryptobyte.String(message)
+	var envelope, response cryptobyte.String
+	var messageID int64
+	var resultCode int
+	if !s.ReadASN1(&envelope, cbasn1.SEQUENCE) ||
+		!envelope.ReadASN1Integer(&messageID) ||
+		!envelope.ReadASN1(&response, ldapExtendedResponse) ||
+		!response.ReadASN1Enum(&resultCode) {
+		return errors.New("malformed ExtendedResponse")
+	}
+	if resultCode != 0 {
+		return fmt.Errorf("server refused StartTLS with result code %d", resultCode)
+	}
+	return nil
+}
+
+// readBER reads one BER element with a definite length.
+func readBER(r *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := int(header[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 3 {
+			return nil, errors.New("unsupported BER length")
+		}
+		extra := make([]byte, n)
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return nil, err
+		}
+		header = append(header, extra...)
+		length = 0
+		for _, b := range extra {
+			length = length<<8 | int(b)
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+func upgradeXMPP(w io.Writer, r *bufio.Reader, host string) error {
+	open := fmt.Sprintf("<?xml version='1.0'?><stream:stream to='%s' xmlns='jabber:client' "+
+		"xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>", host)
+	if _, err := io.WriteString(w, open); err != nil {
+		return err
+	}
+	features, err := readUntil(r, "</stream:features>")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return errors.New("server does not offer STARTTLS")
+	}
+	if _, err := io.WriteString(w, "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+	reply, err := readUntil(r, ">")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(reply, "<proceed") {
+		return fmt.Errorf("server refused STARTTLS: %q", reply)
+	}
+	return nil
+}
+
+// readUntil reads until the data ends with suffix.
+func readUntil(r *bufio.Reader, suffix string) (string, error) {
+	var b strings.Builder
+	for !strings.HasSuffix(b.String(), suffix) {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(c)
+		if b.Len() > 64<<10 {
+			return "", errors.New("response too long")
+		}
+	}
+	return b.String(), nil
+}
+
+// postgresSSLRequest is the length and request code of an SSLRequest message.
+const postgresSSLRequest = 80877103
+
+func upgradePostgres(w io.Writer, r *bufio.Reader) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequest)
+	if _, err := w.Write(request); err != nil {
+		return err
+	}
+	reply, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch reply {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.New("server does not accept SSL connections")
+	}
+	return fmt.Errorf("unexpected reply %q to SSLRequest", reply)
+}
@@ -0,0 +1,246 @@
+package starttls
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer runs the server side of a plaintext exchange. Returning nil
+// starts the TLS handshake.
+type fakeServer func(conn net.Conn, r *bufio.Reader) error
+
+func expectLine(r *bufio.Reader, want string) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if got := strings.TrimRight(line, "\r\n"); got != want {
+		return fmt.Errorf("expected %q, got %q", want, got)
+	}
+	return nil
+}
+
+func smtpServer(extensions ...string) fakeServer {
+	return smtpServerEnding("250 8BITMIME", extensions...)
+}
+
+// smtpServerEnding replies to EHLO with the extensions and then last as the
+// final line.
+func smtpServerEnding(last string, extensions ...string) fakeServer {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		io.WriteString(conn, "220-mail.example ESMTP\r\n220 ready\r\n")
+		if err := expectLine(r, "EHLO keylength-check"); err != nil {
+			return err
+		}
+		reply := "250-mail.example"
+		for _, ext := range extensions {
+			reply += "\r\n250-" + ext
+		}
+		io.WriteString(conn, reply+"\r\n"+last+"\r\n")
+		if err := expectLine(r, "STARTTLS"); err != nil {
+			return err
+		}
+		_, err := io.WriteString(conn, "220 Go ahead\r\n")
+		return err
+	}
+}
+
+func imapServer(conn net.Conn, r *bufio.Reader) error {
+	io.WriteString(conn, "* OK [CAPABILITY IMAP4rev1 STARTTLS] ready\r\n")
+	if err := expectLine(r, "a1 STARTTLS"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "a1 OK Begin TLS negotiation now\r\n")
+	return err
+}
+
+func pop3Server(conn net.Conn, r *bufio.Reader) error {
+	io.WriteString(conn, "+OK POP3 ready\r\n")
+	if err := expectLine(r, "STLS"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "+OK Begin TLS\r\n")
+	return err
+}
+
+func ftpServer(conn net.Conn, r *bufio.Reader) error {
+	io.WriteString(conn, "220 FTP ready\r\n")
+	if err := expectLine(r, "AUTH TLS"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "234 AUTH TLS OK\r\n")
+	return err
+}
+
+func ldapServer(resultCode byte) fakeServer {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		request := make([]byte, len(ldapStartTLS))
+		if _, err := io.ReadFull(r, request); err != nil {
+			return err
+		}
+		if !bytes.Equal(request, ldapStartTLS) {
+			return fmt.Errorf("unexpected request %x", request)
+		}
+		// LDAPMessage { messageID 1, ExtendedResponse { resultCode, "", "" } }
+		conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, resultCode, 0x04, 0x00, 0x04, 0x00})
+		if resultCode != 0 {
+			return errors.New("refused")
+		}
+		return nil
+	}
+}
+
+func xmppServer(conn net.Conn, r *bufio.Reader) error {
+	if _, err := readUntil(r, "version='1.0'>"); err != nil {
+		return err
+	}
+	io.WriteString(conn, "<?xml version='1.0'?><stream:stream from='chat.example' id='1' "+
+		"xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>"+
+		"<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>")
+	if _, err := readUntil(r, "/>"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")
+	return err
+}
+
+func postgresServer(reply byte) fakeServer {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(r, request); err != nil {
+			return err
+		}
+		if !bytes.Equal(request, []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}) {
+			return fmt.Errorf("unexpected SSLRequest %x", request)
+		}
+		conn.Write([]byte{reply})
+		if reply != 'S' {
+			return errors.New("refused")
+		}
+		return nil
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	testCases := []struct {
+		name     string
+		protocol string
+		server   fakeServer
+		wantErr  string
+	}{
+		{"SMTP", SMTP, smtpServer("PIPELINING", "STARTTLS"), ""},
+		{"SMTPWithoutSTARTTLS", SMTP, smtpServer("PIPELINING"), "does not advertise STARTTLS"},
+		{"SMTPBareFinalLine", SMTP, smtpServerEnding("250", "STARTTLS"), ""},
+		{"SMTPBareFinalLineWithoutSTARTTLS", SMTP, smtpServerEnding("250"), "does not advertise STARTTLS"},
+		{"IMAP", IMAP, imapServer, ""},
+		{"POP3", POP3, pop3Server, ""},
+		{"FTP", FTP, ftpServer, ""},
+		{"LDAP", LDAP, ldapServer(0), ""},
+		{"LDAPRefused", LDAP, ldapServer(2), "result code 2"},
+		{"XMPP", XMPP, xmppServer, ""},
+		{"Postgres", Postgres, postgresServer('S'), ""},
+		{"PostgresWithoutSSL", Postgres, postgresServer('N'), "does not accept SSL"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address := startServer(t, tc.server)
+			conn, err := net.DialTimeout("tcp", address, 2*time.Second)
+			if err != nil {
+				t.Fatalf("Failed to dial: %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+			err = Upgrade(conn, tc.protocol, "chat.example")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upgrade() error: %v", err)
+			}
+
+			client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+			if err := client.Handshake(); err != nil {
+				t.Fatalf("TLS handshake after STARTTLS failed: %v", err)
+			}
+			if certs := client.ConnectionState().PeerCertificates; len(certs) != 1 {
+				t.Errorf("Expected 1 certificate, got %d", len(certs))
+			}
+		})
+	}
+}
+
+func TestForPort(t *testing.T) {
+	for port, want := range map[string]string{"25": SMTP, "587": SMTP, "143": IMAP, "110": POP3, "21": FTP,
+		"389": LDAP, "5222": XMPP, "5432": Postgres, "443": "", "465": ""} {
+		if got := ForPort(port); got != want {
+			t.Errorf("ForPort(%s) = %q, expected %q", port, got, want)
+		}
+	}
+	if err := Upgrade(nil, "telnet", ""); err == nil {
+		t.Error("Expected an error for an unsupported protocol")
+	}
+}
+
+// startServer accepts connections, runs the fake exchange on each and then
+// completes a TLS handshake with a self-signed certificate.
+func startServer(t *testing.T, server fakeServer) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				if err := server(conn, bufio.NewReader(conn)); err != nil {
+					return
+				}
+				tls.Server(conn, config).Handshake()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,80 @@
+package targets
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter spaces out connections to the same host. A nil Limiter or one
+// with a zero interval never waits.
+type Limiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+// NewLimiter allows perSecond connections per second to each host. Zero or
+// less means no limit.
+func NewLimiter(perSecond float64) *Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &Limiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until host may be connected to again.
+func (l *Limiter) Wait(host string) {
+	if l == nil || l.interval <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+	time.Sleep(time.Until(slot))
+}
+
+// Run calls scan for each item with at most concurrency calls in flight,
+// and emit with the item's index and result as each completes. emit is
+// never called concurrently, so it may write output or append to slices.
+func Run[T, R any](items []T, concurrency int, scan func(T) R, emit func(int, R)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	type done struct {
+		index  int
+		result R
+	}
+	results := make(chan done)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- done{i, scan(items[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range items {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for d := range results {
+		emit(d.index, d.result)
+	}
+}
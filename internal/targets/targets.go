@@ -0,0 +1,194 @@
+package targets
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxTargets bounds how many targets a CIDR range, port range or file may
+// expand to.
+const MaxTargets = 1 << 16
+
+// Target is a host and port to connect to. Host may be a name or an IP.
+type Target struct {
+	Host string
+	Port string
+}
+
+// Address returns host:port, with IPv6 hosts in brackets.
+func (t Target) Address() string {
+	return net.JoinHostPort(t.Host, t.Port)
+}
+
+// ParsePorts parses a comma-separated list of ports and ranges such as
+// "443,8000-8010".
+func ParsePorts(spec string) ([]string, error) {
+	var ports []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		first, err := parsePort(low)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePort(high); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		for port := first; port <= last; port++ {
+			ports = append(ports, strconv.Itoa(port))
+		}
+		if len(ports) > MaxTargets {
+			return nil, fmt.Errorf("port list %q expands to more than %d ports", spec, MaxTargets)
+		}
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// SplitHostPort separates an optional port from host. Bare IPv6 addresses
+// are returned whole, so a port on one needs brackets: [2001:db8::1]:443.
+func SplitHostPort(s string) (string, string, error) {
+	if strings.HasPrefix(s, "[") || strings.Count(s, ":") == 1 {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := parsePort(port); err != nil {
+			return "", "", err
+		}
+		return host, port, nil
+	}
+	return s, "", nil
+}
+
+// Expand turns a host, IP or CIDR range and a list of ports into targets.
+// A port given with the host, as in host:8443, replaces ports.
+func Expand(spec string, ports []string) ([]Target, error) {
+	host, port, err := SplitHostPort(strings.TrimSpace(spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", spec, err)
+	}
+	if port != "" {
+		ports = []string{port}
+	}
+
+	hosts := []string{host}
+	if strings.Contains(host, "/") {
+		if hosts, err = expandCIDR(host); err != nil {
+			return nil, err
+		}
+	}
+	if len(hosts)*len(ports) > MaxTargets {
+		return nil, fmt.Errorf("target %q expands to more than %d targets", spec, MaxTargets)
+	}
+
+	var targets []Target
+	for _, h := range hosts {
+		for _, p := range ports {
+			targets = append(targets, Target{Host: h, Port: p})
+		}
+	}
+	return targets, nil
+}
+
+// expandCIDR lists the addresses in a prefix. The network and broadcast
+// addresses of IPv4 prefixes shorter than /31 are skipped.
+func expandCIDR(cidr string) ([]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR range %q: %w", cidr, err)
+	}
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > 16 {
+		return nil, fmt.Errorf("CIDR range %q has more than %d addresses", cidr, MaxTargets)
+	}
+
+	var hosts []string
+	skipEnds := prefix.Addr().Is4() && hostBits > 1
+	last := 1<<hostBits - 1
+	addr := prefix.Addr()
+	for i := 0; i <= last; i++ {
+		if !skipEnds || (i != 0 && i != last) {
+			hosts = append(hosts, addr.String())
+		}
+		addr = addr.Next()
+	}
+	return hosts, nil
+}
+
+// Load reads targets from a file with one host[:port] per line, or CSV
+// rows of host,port where port may be a list or range. Hosts may be CIDR
+// ranges, lines starting with '#' are comments and a header row naming a
+// "host" column is skipped. Targets without a port get defaultPorts.
+func Load(path string, defaultPorts []string) ([]Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("failed to open targets file: " + err.Error())
+	}
+	defer f.Close()
+	return parse(f, defaultPorts)
+}
+
+func parse(r io.Reader, defaultPorts []string) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		ports := defaultPorts
+		spec := text
+		if strings.Contains(text, ",") {
+			fields, err := csv.NewReader(strings.NewReader(text)).Read()
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			spec = strings.TrimSpace(fields[0])
+			if strings.EqualFold(spec, "host") {
+				continue
+			}
+			if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+				if ports, err = ParsePorts(strings.ReplaceAll(fields[1], ";", ",")); err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+		}
+
+		expanded, err := Expand(spec, ports)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		targets = append(targets, expanded...)
+		if len(targets) > MaxTargets {
+			return nil, fmt.Errorf("targets file expands to more than %d targets", MaxTargets)
+		}
+	}
+	return targets, scanner.Err()
+}
@@ -0,0 +1,189 @@
+package targets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParsePorts(t *testing.T) {
+	testCases := []struct {
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"443", []string{"443"}, false},
+		{"443, 8443", []string{"443", "8443"}, false},
+		{"443,8000-8002", []string{"443", "8000", "8001", "8002"}, false},
+		{"8002-8000", nil, true},
+		{"0", nil, true},
+		{"65536", nil, true},
+		{"https", nil, true},
+		{"1-65535,1-65535", nil, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParsePorts(tc.spec)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParsePorts(%q) expected an error", tc.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePorts(%q) error: %v", tc.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParsePorts(%q) = %v, expected %v", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	ports := []string{"443", "8443"}
+	testCases := []struct {
+		spec string
+		want []string
+	}{
+		{"example.com", []string{"example.com:443", "example.com:8443"}},
+		{"example.com:9443", []string{"example.com:9443"}},
+		{"2001:db8::1", []string{"[2001:db8::1]:443", "[2001:db8::1]:8443"}},
+		{"[2001:db8::1]:9443", []string{"[2001:db8::1]:9443"}},
+		{"192.0.2.0/30", []string{"192.0.2.1:443", "192.0.2.1:8443", "192.0.2.2:443", "192.0.2.2:8443"}},
+		{"192.0.2.9/32", []string{"192.0.2.9:443", "192.0.2.9:8443"}},
+		{"192.0.2.8/31", []string{"192.0.2.8:443", "192.0.2.8:8443", "192.0.2.9:443", "192.0.2.9:8443"}},
+		{"2001:db8::/127", []string{"[2001:db8::]:443", "[2001:db8::]:8443", "[2001:db8::1]:443", "[2001:db8::1]:8443"}},
+	}
+
+	for _, tc := range testCases {
+		targets, err := Expand(tc.spec, ports)
+		if err != nil {
+			t.Errorf("Expand(%q) error: %v", tc.spec, err)
+			continue
+		}
+		var got []string
+		for _, target := range targets {
+			got = append(got, target.Address())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expand(%q) = %v, expected %v", tc.spec, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"10.0.0.0/8", "192.0.2.0/33", "example.com:0", "[2001:db8::1]"} {
+		if _, err := Expand(spec, ports); err == nil {
+			t.Errorf("Expand(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	content := `# production edge
+host,port
+www.example.com
+api.example.com:8443
+"mail.example.com",25;587
+lb.example.com,9000-9001
+192.0.2.0/30
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write targets file: %v", err)
+	}
+
+	targets, err := Load(path, []string{"443"})
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.Address())
+	}
+	want := []string{
+		"www.example.com:443",
+		"api.example.com:8443",
+		"mail.example.com:25", "mail.example.com:587",
+		"lb.example.com:9000", "lb.example.com:9001",
+		"192.0.2.1:443", "192.0.2.2:443",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, expected %v", got, want)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.txt")
+	os.WriteFile(bad, []byte("www.example.com\nexample.com,http\n"), 0o600)
+	if _, err := Load(bad, []string{"443"}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error naming line 2, got %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt"), nil); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestRun(t *testing.T) {
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	scan := func(n int) int {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return n * n
+	}
+
+	results := make([]int, len(items))
+	emitted := 0
+	Run(items, 4, scan, func(i, result int) {
+		results[i] = result
+		emitted++
+	})
+
+	if emitted != len(items) {
+		t.Fatalf("Expected %d results, got %d", len(items), emitted)
+	}
+	for i, result := range results {
+		if result != i*i {
+			t.Errorf("Result %d = %d, expected %d", i, result, i*i)
+		}
+	}
+	if maxInFlight > 4 {
+		t.Errorf("Expected at most 4 scans in flight, got %d", maxInFlight)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(20)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait("192.0.2.1")
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected three connections to one host to take at least 100ms, took %s", elapsed)
+	}
+
+	start = time.Now()
+	limiter.Wait("192.0.2.2")
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("Expected another host not to wait, waited %s", elapsed)
+	}
+
+	if NewLimiter(0) != nil {
+		t.Error("Expected no limiter for a rate of 0")
+	}
+	var unlimited *Limiter
+	unlimited.Wait("192.0.2.1")
+}
@@ -0,0 +1,112 @@
+package tlsprobe
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Group is a named key exchange group. Bits is the size the standard's
+// thresholds are compared against: the field size for elliptic curves, with
+// X25519 counted as 256, and the prime size for finite-field groups. Hybrid
+// groups are sized by their classical component.
+type Group struct {
+	ID   uint16
+	Name string
+	Kind string
+	Bits int
+}
+
+const (
+	KindECDHE  = "ECDHE"
+	KindFFDHE  = "FFDHE"
+	KindHybrid = "Hybrid"
+)
+
+const (
+	GroupP256           uint16 = 0x0017
+	GroupP384           uint16 = 0x0018
+	GroupP521           uint16 = 0x0019
+	GroupX25519         uint16 = 0x001d
+	GroupFFDHE2048      uint16 = 0x0100
+	GroupFFDHE3072      uint16 = 0x0101
+	GroupFFDHE4096      uint16 = 0x0102
+	GroupFFDHE6144      uint16 = 0x0103
+	GroupFFDHE8192      uint16 = 0x0104
+	GroupX25519MLKEM768 uint16 = 0x11ec
+)
+
+// Groups is the catalog of named groups probed.
+var Groups = []Group{
+	{GroupX25519, "X25519", KindECDHE, 256},
+	{GroupP256, "P-256", KindECDHE, 256},
+	{GroupP384, "P-384", KindECDHE, 384},
+	{GroupP521, "P-521", KindECDHE, 521},
+	{GroupFFDHE2048, "ffdhe2048", KindFFDHE, 2048},
+	{GroupFFDHE3072, "ffdhe3072", KindFFDHE, 3072},
+	{GroupFFDHE4096, "ffdhe4096", KindFFDHE, 4096},
+	{GroupFFDHE6144, "ffdhe6144", KindFFDHE, 6144},
+	{GroupFFDHE8192, "ffdhe8192", KindFFDHE, 8192},
+	{GroupX25519MLKEM768, "X25519MLKEM768", KindHybrid, 256},
+}
+
+var (
+	ffdhePrimesOnce sync.Once
+	ffdhePrimes     map[uint16]*big.Int
+)
+
+// ffdhePrime returns the RFC 7919 prime of a finite-field group. The primes
+// are derived from e as the RFC defines them rather than copied in as hex:
+//
+//	p = 2^b - 2^{b-64} + {[2^{b-130} e] + X} * 2^64 - 1
+func ffdhePrime(group uint16) *big.Int {
+	ffdhePrimesOnce.Do(func() {
+		ffdhePrimes = map[uint16]*big.Int{
+			GroupFFDHE2048: deriveFFDHEPrime(2048, 560316),
+			GroupFFDHE3072: deriveFFDHEPrime(3072, 2625351),
+			GroupFFDHE4096: deriveFFDHEPrime(4096, 5736041),
+			GroupFFDHE6144: deriveFFDHEPrime(6144, 15705020),
+			GroupFFDHE8192: deriveFFDHEPrime(8192, 10965728),
+		}
+	})
+	return ffdhePrimes[group]
+}
+
+func deriveFFDHEPrime(bits int, x int64) *big.Int {
+	prec := uint(bits + 128)
+	e := new(big.Float).SetPrec(prec).SetInt64(1)
+	term := new(big.Float).SetPrec(prec).SetInt64(1)
+	for k := int64(1); term.MantExp(nil) > -int(prec); k++ {
+		term.Quo(term, new(big.Float).SetInt64(k))
+		e.Add(e, term)
+	}
+
+	m, _ := new(big.Float).SetMantExp(e, bits-130).Int(nil)
+	m.Add(m, big.NewInt(x))
+	m.Lsh(m, 64)
+
+	one := big.NewInt(1)
+	p := new(big.Int).Lsh(one, uint(bits))
+	p.Sub(p, new(big.Int).Lsh(one, uint(bits-64)))
+	p.Add(p, m)
+	return p.Sub(p, one)
+}
+
+// namedFFDHEGroup returns the RFC 7919 group whose prime is p, if any.
+func namedFFDHEGroup(p *big.Int) (Group, bool) {
+	for _, group := range Groups {
+		if group.Kind == KindFFDHE && ffdhePrime(group.ID).Cmp(p) == 0 {
+			return group, true
+		}
+	}
+	return Group{}, false
+}
+
+// GroupByID looks up a group in the catalog.
+func GroupByID(id uint16) (Group, bool) {
+	for _, g := range Groups {
+		if g.ID == id {
+			return g, true
+		}
+	}
+	return Group{}, false
+}
@@ -0,0 +1,284 @@
+package tlsprobe
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	recordHandshake = 22
+	recordAlert     = 21
+
+	typeClientHello       = 1
+	typeServerHello       = 2
+	typeServerKeyExchange = 12
+	typeServerHelloDone   = 14
+
+	extServerName          = 0
+	extSupportedGroups     = 10
+	extPointFormats        = 11
+	extSignatureAlgorithms = 13
+	extSupportedVersions   = 43
+	extKeyShare            = 51
+	extRenegotiationInfo   = 0xff01
+)
+
+// helloRetryRandom marks a TLS 1.3 ServerHello as a HelloRetryRequest.
+var helloRetryRandom = []byte{
+	0xcf, 0x21, 0xad, 0x74, 0xe5, 0x9a, 0x61, 0x11, 0xbe, 0x1d, 0x8c, 0x02, 0x1e, 0x65, 0xb8, 0x91,
+	0xc2, 0xa2, 0x11, 0x16, 0x7a, 0xbb, 0x8c, 0x5e, 0x07, 0x9e, 0x09, 0xe2, 0xc8, 0xa8, 0x33, 0x9c,
+}
+
+var signatureAlgorithms = []uint16{
+	0x0403, 0x0503, 0x0603, // ECDSA with SHA-256, SHA-384, SHA-512
+	0x0804, 0x0805, 0x0806, // RSA-PSS with SHA-256, SHA-384, SHA-512
+	0x0807,                 // Ed25519
+	0x0401, 0x0501, 0x0601, // RSA PKCS #1 v1.5 with SHA-256, SHA-384, SHA-512
+	0x0201, 0x0203, // SHA-1 with RSA and ECDSA
+}
+
+// errRejected is returned when the server answers a ClientHello with an alert
+// or closes the connection, meaning it supports none of what was offered.
+var errRejected = errors.New("handshake rejected")
+
+// clientHello holds what a probe offers. Only the ServerHello is read, so key
+// shares need a valid encoding but are never used.
+type clientHello struct {
+	version    uint16
+	suites     []uint16
+	serverName string
+	groups     []uint16
+	keyShares  []uint16
+}
+
+type serverHello struct {
+	version    uint16
+	suite      uint16
+	group      uint16
+	helloRetry bool
+}
+
+func (h *clientHello) marshal() ([]byte, error) {
+	legacyVersion := h.version
+	if legacyVersion > VersionTLS12 {
+		legacyVersion = VersionTLS12
+	}
+	random := make([]byte, 32)
+	sessionID := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(sessionID); err != nil {
+		return nil, err
+	}
+	shares, err := h.marshalKeyShares()
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(typeClientHello)
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16(legacyVersion)
+		b.AddBytes(random)
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(sessionID) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			for _, suite := range h.suites {
+				b.AddUint16(suite)
+			}
+		})
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(0) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			if h.serverName != "" && net.ParseIP(h.serverName) == nil {
+				addExtension(b, extServerName, func(b *cryptobyte.Builder) {
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						b.AddUint8(0)
+						b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(h.serverName)) })
+					})
+				})
+			}
+			addExtension(b, extSupportedGroups, func(b *cryptobyte.Builder) {
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					for _, group := range h.groups {
+						b.AddUint16(group)
+					}
+				})
+			})
+			addExtension(b, extPointFormats, func(b *cryptobyte.Builder) {
+				b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(0) })
+			})
+			addExtension(b, extSignatureAlgorithms, func(b *cryptobyte.Builder) {
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					for _, alg := range signatureAlgorithms {
+						b.AddUint16(alg)
+					}
+				})
+			})
+			addExtension(b, extRenegotiationInfo, func(b *cryptobyte.Builder) { b.AddUint8(0) })
+			if h.version >= VersionTLS13 {
+				addExtension(b, extSupportedVersions, func(b *cryptobyte.Builder) {
+					b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint16(h.version) })
+				})
+				addExtension(b, extKeyShare, func(b *cryptobyte.Builder) {
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(shares) })
+				})
+			}
+		})
+	})
+	message, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var record cryptobyte.Builder
+	record.AddUint8(recordHandshake)
+	record.AddUint16(VersionTLS10)
+	record.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(message) })
+	return record.Bytes()
+}
+
+func (h *clientHello) marshalKeyShares() ([]byte, error) {
+	var b cryptobyte.Builder
+	for _, group := range h.keyShares {
+		share, err := keyShare(group)
+		if err != nil {
+			return nil, err
+		}
+		b.AddUint16(group)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(share) })
+	}
+	return b.Bytes()
+}
+
+// keyShare returns a public key for group, which the probe throws away.
+func keyShare(group uint16) ([]byte, error) {
+	var curve ecdh.Curve
+	switch group {
+	case GroupX25519:
+		curve = ecdh.X25519()
+	case GroupP256:
+		curve = ecdh.P256()
+	case GroupP384:
+		curve = ecdh.P384()
+	case GroupP521:
+		curve = ecdh.P521()
+	default:
+		return nil, fmt.Errorf("no key share for group 0x%04x", group)
+	}
+	key, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+func addExtension(b *cryptobyte.Builder, ext uint16, body cryptobyte.BuilderContinuation) {
+	b.AddUint16(ext)
+	b.AddUint16LengthPrefixed(body)
+}
+
+// handshakeReader splits handshake records into messages. An alert or a
+// closed connection is reported as errRejected.
+type handshakeReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (h *handshakeReader) next() (uint8, []byte, error) {
+	header := make([]byte, 5)
+	for {
+		if len(h.buf) >= 4 {
+			size := int(h.buf[1])<<16 | int(h.buf[2])<<8 | int(h.buf[3])
+			if len(h.buf) >= 4+size {
+				typ, body := h.buf[0], h.buf[4:4+size]
+				h.buf = h.buf[4+size:]
+				return typ, body, nil
+			}
+		}
+
+		if _, err := io.ReadFull(h.r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+				return 0, nil, errRejected
+			}
+			var opErr *net.OpError
+			if errors.As(err, &opErr) && !opErr.Timeout() {
+				return 0, nil, errRejected
+			}
+			return 0, nil, err
+		}
+		length := int(header[3])<<8 | int(header[4])
+		if length > 1<<14+2048 {
+			return 0, nil, errors.New("TLS record too long")
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(h.r, body); err != nil {
+			return 0, nil, errRejected
+		}
+
+		switch header[0] {
+		case recordAlert:
+			return 0, nil, errRejected
+		case recordHandshake:
+			h.buf = append(h.buf, body...)
+		default:
+			return 0, nil, fmt.Errorf("unexpected TLS record type %d", header[0])
+		}
+	}
+}
+
+func readServerHello(h *handshakeReader) (*serverHello, error) {
+	typ, body, err := h.next()
+	if err != nil {
+		return nil, err
+	}
+	if typ != typeServerHello {
+		return nil, fmt.Errorf("unexpected handshake message type %d", typ)
+	}
+	return parseServerHello(body)
+}
+
+func parseServerHello(data []byte) (*serverHello, error) {
+	s := cryptobyte.String(data)
+	hello := &serverHello{}
+	var random, sessionID []byte
+	var compression uint8
+	if !s.ReadUint16(&hello.version) || !s.ReadBytes(&random, 32) ||
+		!s.ReadUint8LengthPrefixed((*cryptobyte.String)(&sessionID)) ||
+		!s.ReadUint16(&hello.suite) || !s.ReadUint8(&compression) {
+		return nil, errors.New("malformed ServerHello")
+	}
+	hello.helloRetry = bytes.Equal(random, helloRetryRandom)
+	if s.Empty() {
+		return hello, nil
+	}
+
+	var extensions cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&extensions) {
+		return nil, errors.New("malformed ServerHello extensions")
+	}
+	for !extensions.Empty() {
+		var ext uint16
+		var body cryptobyte.String
+		if !extensions.ReadUint16(&ext) || !extensions.ReadUint16LengthPrefixed(&body) {
+			return nil, errors.New("malformed ServerHello extension")
+		}
+		switch ext {
+		case extSupportedVersions:
+			if !body.ReadUint16(&hello.version) {
+				return nil, errors.New("malformed supported_versions extension")
+			}
+		case extKeyShare:
+			if !body.ReadUint16(&hello.group) {
+				return nil, errors.New("malformed key_share extension")
+			}
+		}
+	}
+	return hello, nil
+}
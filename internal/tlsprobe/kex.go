@@ -0,0 +1,156 @@
+package tlsprobe
+
+import (
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// AcceptedGroup is a group the server negotiated, with the highest version it
+// was negotiated at.
+type AcceptedGroup struct {
+	Group
+	Version uint16
+}
+
+// KeyExchange lists the named groups a server accepts. DHE is the group used
+// for DHE suites when the client names no finite-field group, sized from the
+// prime in the ServerKeyExchange; it is nil when no DHE suite is accepted.
+type KeyExchange struct {
+	Groups []AcceptedGroup
+	DHE    *AcceptedGroup
+}
+
+// KeyExchange probes every group in Groups, then the server's own DHE prime.
+func (p *Prober) KeyExchange() (*KeyExchange, error) {
+	kex := &KeyExchange{}
+	for _, group := range Groups {
+		version, err := p.probeGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		if version != 0 {
+			kex.Groups = append(kex.Groups, AcceptedGroup{Group: group, Version: version})
+		}
+	}
+
+	dhe, err := p.probeDHE(defaultGroups)
+	if err != nil {
+		return nil, err
+	}
+	kex.DHE = dhe
+	return kex, nil
+}
+
+// probeGroup returns the version a group was accepted at, or zero. TLS 1.3 is
+// tried first with no key share, so a server supporting the group has to name
+// it in a HelloRetryRequest. Otherwise it falls back to TLS 1.2 suites whose
+// key exchange can only use the group offered.
+func (p *Prober) probeGroup(group Group) (uint16, error) {
+	hello := &clientHello{
+		version:    VersionTLS13,
+		suites:     suiteIDs(func(s Suite) bool { return s.offeredIn(VersionTLS13) }),
+		serverName: p.ServerName,
+		groups:     []uint16{group.ID},
+	}
+	server, err := p.handshake(hello)
+	if err != nil && !errors.Is(err, errRejected) {
+		return 0, err
+	}
+	if err == nil && server.version == VersionTLS13 && server.group == group.ID {
+		return VersionTLS13, nil
+	}
+
+	switch group.Kind {
+	case KindECDHE:
+		hello := &clientHello{
+			version:    VersionTLS12,
+			suites:     suiteIDs(func(s Suite) bool { return s.KeyExchange == "ECDHE" }),
+			serverName: p.ServerName,
+			groups:     []uint16{group.ID},
+		}
+		server, err := p.handshake(hello)
+		if errors.Is(err, errRejected) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return server.version, nil
+	case KindFFDHE:
+		dhe, err := p.probeDHE([]uint16{group.ID})
+		if err != nil || dhe == nil || dhe.ID != group.ID {
+			return 0, err
+		}
+		return dhe.Version, nil
+	}
+	return 0, nil
+}
+
+// probeDHE offers only DHE suites and reads the prime from the server's
+// ServerKeyExchange. Primes from RFC 7919 are reported as their named group.
+func (p *Prober) probeDHE(groups []uint16) (*AcceptedGroup, error) {
+	hello := &clientHello{
+		version:    VersionTLS12,
+		suites:     suiteIDs(func(s Suite) bool { return s.KeyExchange == "DHE" }),
+		serverName: p.ServerName,
+		groups:     groups,
+	}
+
+	var version uint16
+	var prime *big.Int
+	err := p.exchange(hello, func(r *handshakeReader) error {
+		server, err := readServerHello(r)
+		if err != nil {
+			return err
+		}
+		version = server.version
+		for {
+			typ, body, err := r.next()
+			if err != nil {
+				return err
+			}
+			switch typ {
+			case typeServerKeyExchange:
+				prime, err = parseDHPrime(body)
+				return err
+			case typeServerHelloDone:
+				return errors.New("server sent no ServerKeyExchange for a DHE suite")
+			}
+		}
+	})
+	if errors.Is(err, errRejected) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	group, ok := namedFFDHEGroup(prime)
+	if !ok {
+		group = Group{Name: "DHE", Kind: KindFFDHE, Bits: prime.BitLen()}
+	}
+	return &AcceptedGroup{Group: group, Version: version}, nil
+}
+
+// parseDHPrime reads dh_p from ServerDHParams, which starts the body of a DHE
+// ServerKeyExchange.
+func parseDHPrime(body []byte) (*big.Int, error) {
+	s := cryptobyte.String(body)
+	var p cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&p) || len(p) == 0 {
+		return nil, errors.New("malformed DHE ServerKeyExchange")
+	}
+	return new(big.Int).SetBytes(p), nil
+}
+
+func suiteIDs(include func(Suite) bool) []uint16 {
+	var ids []uint16
+	for _, s := range Suites {
+		if include(s) {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
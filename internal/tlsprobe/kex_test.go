@@ -0,0 +1,181 @@
+package tlsprobe
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+func TestFFDHEPrimes(t *testing.T) {
+	// SHA-256 of each prime in lowercase hex, as printed by OpenSSL.
+	want := map[uint16]string{
+		GroupFFDHE2048: "b9fd49b47ad1363ebf1681ab8a5b6c3bb0be15897d0d94aff227ee91b867ab8a",
+		GroupFFDHE3072: "c5288e890a7a8da070e69a9d23fa0e264aeddfaa05e267fbd038c4be1210d6a2",
+		GroupFFDHE4096: "3cb2119eb37a64531e61b96555689035640b6d80145f303d321c1a58ce15e936",
+		GroupFFDHE6144: "0d49e72527f7d91e8a357aa015594fe4f801c88a9123738af0e2ec4a0ad04a8b",
+		GroupFFDHE8192: "461db4b7734852427de4b4289cebd2feb853fa474ea72f9faf84a17fb05e3f0b",
+	}
+	for id, digest := range want {
+		group, _ := GroupByID(id)
+		t.Run(group.Name, func(t *testing.T) {
+			p := ffdhePrime(id)
+			if p.BitLen() != group.Bits {
+				t.Errorf("Expected %d-bit prime, got %d bits", group.Bits, p.BitLen())
+			}
+			sum := sha256.Sum256([]byte(p.Text(16)))
+			if got := hex.EncodeToString(sum[:]); got != digest {
+				t.Errorf("Prime does not match RFC 7919: digest %s", got)
+			}
+			if named, ok := namedFFDHEGroup(p); !ok || named.ID != id {
+				t.Errorf("Expected prime to be recognised as %s", group.Name)
+			}
+		})
+	}
+}
+
+func TestKeyExchange(t *testing.T) {
+	testCases := []struct {
+		name       string
+		config     *tls.Config
+		wantGroups map[uint16]uint16
+	}{
+		{
+			name:   "TLS13Defaults",
+			config: &tls.Config{MinVersion: tls.VersionTLS13},
+			wantGroups: map[uint16]uint16{
+				GroupX25519:         VersionTLS13,
+				GroupP256:           VersionTLS13,
+				GroupP384:           VersionTLS13,
+				GroupP521:           VersionTLS13,
+				GroupX25519MLKEM768: VersionTLS13,
+			},
+		},
+		{
+			name:       "TLS12SingleCurve",
+			config:     &tls.Config{MaxVersion: tls.VersionTLS12, CurvePreferences: []tls.CurveID{tls.CurveP256}},
+			wantGroups: map[uint16]uint16{GroupP256: VersionTLS12},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prober := &Prober{Address: startServer(t, tc.config), ServerName: "localhost", Timeout: 2 * time.Second}
+			kex, err := prober.KeyExchange()
+			if err != nil {
+				t.Fatalf("KeyExchange() error: %v", err)
+			}
+			if len(kex.Groups) != len(tc.wantGroups) {
+				t.Errorf("Expected %d groups, got %+v", len(tc.wantGroups), kex.Groups)
+			}
+			for _, accepted := range kex.Groups {
+				if version, ok := tc.wantGroups[accepted.ID]; !ok || version != accepted.Version {
+					t.Errorf("Unexpected group %s at %s", accepted.Name, VersionName(accepted.Version))
+				}
+			}
+			if kex.DHE != nil {
+				t.Errorf("Expected no DHE support from crypto/tls, got %+v", kex.DHE)
+			}
+		})
+	}
+}
+
+func TestProbeDHE(t *testing.T) {
+	custom, _ := new(big.Int).SetString("f"+hex.EncodeToString(make([]byte, 127))+"7", 16)
+
+	testCases := []struct {
+		name     string
+		prime    *big.Int
+		wantName string
+		wantBits int
+	}{
+		{"NamedGroup", ffdhePrime(GroupFFDHE3072), "ffdhe3072", 3072},
+		{"CustomPrime", custom, "DHE", 1024},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prober := &Prober{Address: startDHEServer(t, tc.prime), Timeout: 2 * time.Second}
+			dhe, err := prober.probeDHE(defaultGroups)
+			if err != nil {
+				t.Fatalf("probeDHE() error: %v", err)
+			}
+			if dhe == nil {
+				t.Fatal("Expected DHE to be accepted")
+			}
+			if dhe.Name != tc.wantName || dhe.Bits != tc.wantBits || dhe.Version != VersionTLS12 {
+				t.Errorf("Expected %s with %d bits at TLS 1.2, got %+v", tc.wantName, tc.wantBits, dhe)
+			}
+		})
+	}
+}
+
+// startDHEServer answers every ClientHello with a TLS 1.2 DHE handshake up
+// to ServerHelloDone, using prime in its ServerKeyExchange.
+func startDHEServer(t *testing.T, prime *big.Int) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var b cryptobyte.Builder
+	addMessage := func(typ uint8, body func(b *cryptobyte.Builder)) {
+		b.AddUint8(typ)
+		b.AddUint24LengthPrefixed(body)
+	}
+	addMessage(typeServerHello, func(b *cryptobyte.Builder) {
+		b.AddUint16(VersionTLS12)
+		b.AddBytes(make([]byte, 32))
+		b.AddUint8(0)
+		b.AddUint16(0x0033)
+		b.AddUint8(0)
+	})
+	addMessage(11, func(b *cryptobyte.Builder) {
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {})
+	})
+	addMessage(typeServerKeyExchange, func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(prime.Bytes()) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(2) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(4) })
+	})
+	addMessage(typeServerHelloDone, func(b *cryptobyte.Builder) {})
+	messages := b.BytesOrPanic()
+
+	// Split the flight across records to exercise message reassembly.
+	var records []byte
+	for len(messages) > 0 {
+		n := min(len(messages), 100)
+		records = append(records, recordHandshake, 3, 3, byte(n>>8), byte(n))
+		records = append(records, messages[:n]...)
+		messages = messages[n:]
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				header := make([]byte, 5)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				if _, err := io.CopyN(io.Discard, conn, int64(header[3])<<8|int64(header[4])); err != nil {
+					return
+				}
+				conn.Write(records)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
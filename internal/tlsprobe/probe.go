@@ -0,0 +1,133 @@
+package tlsprobe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+var defaultGroups = []uint16{GroupX25519, GroupP256, GroupP384, GroupP521}
+
+// Prober sends hand-built ClientHellos and reads only the ServerHello, so it
+// can offer suites and versions crypto/tls refuses to negotiate.
+type Prober struct {
+	Address    string
+	ServerName string
+	Timeout    time.Duration
+	// Upgrade, if set, runs on each connection before the ClientHello is
+	// sent, such as a STARTTLS exchange.
+	Upgrade func(net.Conn) error
+	// Dial, if set, opens each connection instead of net.DialTimeout.
+	Dial func(network, address string) (net.Conn, error)
+}
+
+// Protocol records whether a version is accepted and the suites accepted with
+// it, in the server's order of preference.
+type Protocol struct {
+	Version   uint16
+	Name      string
+	Supported bool
+	Suites    []Suite
+}
+
+// Enumerate probes every version in Versions. Each version is probed by
+// offering all its suites and removing the one the server picks until the
+// server rejects what is left.
+func (p *Prober) Enumerate() ([]Protocol, error) {
+	var protocols []Protocol
+	for _, version := range Versions {
+		protocol := Protocol{Version: version, Name: VersionName(version)}
+		var remaining []Suite
+		for _, s := range Suites {
+			if s.offeredIn(version) {
+				remaining = append(remaining, s)
+			}
+		}
+
+		for len(remaining) > 0 {
+			hello := &clientHello{version: version, serverName: p.ServerName, groups: defaultGroups}
+			for _, s := range remaining {
+				hello.suites = append(hello.suites, s.ID)
+			}
+			if version >= VersionTLS13 {
+				hello.keyShares = []uint16{GroupX25519}
+			}
+
+			server, err := p.handshake(hello)
+			if errors.Is(err, errRejected) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if server.version != version {
+				break
+			}
+
+			chosen := -1
+			for i, s := range remaining {
+				if s.ID == server.suite {
+					chosen = i
+					break
+				}
+			}
+			if chosen < 0 {
+				return nil, fmt.Errorf("%s: server chose cipher suite 0x%04x, which was not offered", protocol.Name, server.suite)
+			}
+			protocol.Supported = true
+			protocol.Suites = append(protocol.Suites, remaining[chosen])
+			remaining = append(remaining[:chosen], remaining[chosen+1:]...)
+		}
+		protocols = append(protocols, protocol)
+	}
+	return protocols, nil
+}
+
+// handshake sends one ClientHello on a new connection and returns the reply.
+func (p *Prober) handshake(hello *clientHello) (*serverHello, error) {
+	var server *serverHello
+	err := p.exchange(hello, func(r *handshakeReader) (err error) {
+		server, err = readServerHello(r)
+		return err
+	})
+	return server, err
+}
+
+// exchange sends one ClientHello on a new connection and hands the server's
+// handshake messages to read.
+func (p *Prober) exchange(hello *clientHello, read func(*handshakeReader) error) error {
+	record, err := hello.marshal()
+	if err != nil {
+		return err
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	dial := p.Dial
+	if dial == nil {
+		dial = func(network, address string) (net.Conn, error) {
+			return net.DialTimeout(network, address, timeout)
+		}
+	}
+	conn, err := dial("tcp", p.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if p.Upgrade != nil {
+		if err := p.Upgrade(conn); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Write(record); err != nil {
+		return errRejected
+	}
+	return read(&handshakeReader{r: conn})
+}
@@ -0,0 +1,150 @@
+package tlsprobe
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEnumerate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		config     *tls.Config
+		wantSuites map[uint16][]uint16
+	}{
+		{
+			name:   "TLS13Only",
+			config: &tls.Config{MinVersion: tls.VersionTLS13},
+			wantSuites: map[uint16][]uint16{
+				VersionTLS13: {tls.TLS_AES_128_GCM_SHA256, tls.TLS_AES_256_GCM_SHA384, tls.TLS_CHACHA20_POLY1305_SHA256},
+			},
+		},
+		{
+			name: "LegacySuites",
+			config: &tls.Config{
+				MinVersion: tls.VersionTLS10,
+				MaxVersion: tls.VersionTLS12,
+				CipherSuites: []uint16{
+					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+					tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+					tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+					tls.TLS_RSA_WITH_RC4_128_SHA,
+				},
+			},
+			wantSuites: map[uint16][]uint16{
+				VersionTLS10: {tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA, tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA, tls.TLS_RSA_WITH_RC4_128_SHA},
+				VersionTLS11: {tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA, tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA, tls.TLS_RSA_WITH_RC4_128_SHA},
+				VersionTLS12: {tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+					tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA, tls.TLS_RSA_WITH_RC4_128_SHA},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prober := &Prober{Address: startServer(t, tc.config), ServerName: "localhost", Timeout: 2 * time.Second}
+			protocols, err := prober.Enumerate()
+			if err != nil {
+				t.Fatalf("Enumerate() error: %v", err)
+			}
+			if len(protocols) != len(Versions) {
+				t.Fatalf("Expected %d protocols, got %d", len(Versions), len(protocols))
+			}
+
+			for _, protocol := range protocols {
+				want := tc.wantSuites[protocol.Version]
+				if protocol.Supported != (len(want) > 0) {
+					t.Errorf("%s: expected supported=%v", protocol.Name, len(want) > 0)
+				}
+				got := map[uint16]bool{}
+				for _, s := range protocol.Suites {
+					got[s.ID] = true
+				}
+				if len(got) != len(want) {
+					t.Errorf("%s: expected %d suites, got %+v", protocol.Name, len(want), protocol.Suites)
+				}
+				for _, id := range want {
+					if !got[id] {
+						t.Errorf("%s: expected suite %s to be accepted", protocol.Name, tls.CipherSuiteName(id))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEnumerateConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	prober := &Prober{Address: address, Timeout: time.Second}
+	if _, err := prober.Enumerate(); err == nil {
+		t.Error("Expected an error when nothing is listening")
+	}
+}
+
+func TestSuiteCatalog(t *testing.T) {
+	seen := map[uint16]bool{}
+	for _, s := range Suites {
+		if seen[s.ID] {
+			t.Errorf("Suite 0x%04x is listed twice", s.ID)
+		}
+		seen[s.ID] = true
+		if name := tls.CipherSuiteName(s.ID); name[0] != '0' && name != s.Name {
+			t.Errorf("Suite 0x%04x: expected name %s, got %s", s.ID, name, s.Name)
+		}
+	}
+}
+
+// startServer runs a crypto/tls server with a self-signed RSA certificate
+// that completes handshakes until the test ends.
+func startServer(t *testing.T, config *tls.Config) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	config.Certificates = []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
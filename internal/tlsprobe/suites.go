@@ -0,0 +1,159 @@
+package tlsprobe
+
+import "fmt"
+
+const (
+	VersionTLS10 uint16 = 0x0301
+	VersionTLS11 uint16 = 0x0302
+	VersionTLS12 uint16 = 0x0303
+	VersionTLS13 uint16 = 0x0304
+)
+
+// Versions lists the protocol versions probed, oldest first.
+var Versions = []uint16{VersionTLS10, VersionTLS11, VersionTLS12, VersionTLS13}
+
+// VersionName returns the conventional name of a protocol version.
+func VersionName(version uint16) string {
+	switch version {
+	case VersionTLS10:
+		return "TLS 1.0"
+	case VersionTLS11:
+		return "TLS 1.1"
+	case VersionTLS12:
+		return "TLS 1.2"
+	case VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// Suite describes a cipher suite's key exchange and bulk cipher. KeyBits is
+// the effective strength of the bulk cipher key, so 3DES counts as 112 bits
+// and export suites as 40 or 56.
+type Suite struct {
+	ID          uint16
+	Name        string
+	KeyExchange string
+	Cipher      string
+	Mode        string
+	KeyBits     int
+	Export      bool
+
+	minVersion uint16
+	maxVersion uint16
+}
+
+// AEAD reports whether the bulk cipher is an authenticated encryption mode.
+func (s Suite) AEAD() bool {
+	return s.Mode == "GCM" || s.Mode == "CCM" || s.Mode == "Poly1305"
+}
+
+// Anonymous reports whether the key exchange leaves the server unauthenticated.
+func (s Suite) Anonymous() bool {
+	return s.KeyExchange == "DH_anon" || s.KeyExchange == "ECDH_anon"
+}
+
+// BulkCipher names the cipher, key size and mode, such as AES-128-GCM.
+func (s Suite) BulkCipher() string {
+	switch s.Cipher {
+	case "NULL":
+		return "NULL"
+	case "RC4":
+		return fmt.Sprintf("RC4-%d", s.KeyBits)
+	case "3DES":
+		return "3DES-EDE-CBC"
+	case "CHACHA20":
+		return "ChaCha20-Poly1305"
+	}
+	return fmt.Sprintf("%s-%d-%s", s.Cipher, s.KeyBits, s.Mode)
+}
+
+// offeredIn reports whether the suite may be negotiated at version.
+func (s Suite) offeredIn(version uint16) bool {
+	return version >= s.minVersion && version <= s.maxVersion
+}
+
+func suite(id uint16, name, kex, cipher, mode string, bits int, minVersion, maxVersion uint16) Suite {
+	return Suite{ID: id, Name: name, KeyExchange: kex, Cipher: cipher, Mode: mode, KeyBits: bits,
+		minVersion: minVersion, maxVersion: maxVersion}
+}
+
+func exportSuite(id uint16, name, kex, cipher, mode string, bits int) Suite {
+	s := suite(id, name, kex, cipher, mode, bits, VersionTLS10, VersionTLS10)
+	s.Export = true
+	return s
+}
+
+// Suites is the catalog of cipher suites probed, including ones crypto/tls
+// does not implement so servers still offering them are caught.
+var Suites = []Suite{
+	suite(0x1301, "TLS_AES_128_GCM_SHA256", "", "AES", "GCM", 128, VersionTLS13, VersionTLS13),
+	suite(0x1302, "TLS_AES_256_GCM_SHA384", "", "AES", "GCM", 256, VersionTLS13, VersionTLS13),
+	suite(0x1303, "TLS_CHACHA20_POLY1305_SHA256", "", "CHACHA20", "Poly1305", 256, VersionTLS13, VersionTLS13),
+	suite(0x1304, "TLS_AES_128_CCM_SHA256", "", "AES", "CCM", 128, VersionTLS13, VersionTLS13),
+	suite(0x1305, "TLS_AES_128_CCM_8_SHA256", "", "AES", "CCM", 128, VersionTLS13, VersionTLS13),
+
+	suite(0xc02b, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "ECDHE", "AES", "GCM", 128, VersionTLS12, VersionTLS12),
+	suite(0xc02c, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "ECDHE", "AES", "GCM", 256, VersionTLS12, VersionTLS12),
+	suite(0xc02f, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "ECDHE", "AES", "GCM", 128, VersionTLS12, VersionTLS12),
+	suite(0xc030, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "ECDHE", "AES", "GCM", 256, VersionTLS12, VersionTLS12),
+	suite(0xcca9, "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256", "ECDHE", "CHACHA20", "Poly1305", 256, VersionTLS12, VersionTLS12),
+	suite(0xcca8, "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", "ECDHE", "CHACHA20", "Poly1305", 256, VersionTLS12, VersionTLS12),
+	suite(0x009e, "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", "DHE", "AES", "GCM", 128, VersionTLS12, VersionTLS12),
+	suite(0x009f, "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384", "DHE", "AES", "GCM", 256, VersionTLS12, VersionTLS12),
+	suite(0xccaa, "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256", "DHE", "CHACHA20", "Poly1305", 256, VersionTLS12, VersionTLS12),
+	suite(0x009c, "TLS_RSA_WITH_AES_128_GCM_SHA256", "RSA", "AES", "GCM", 128, VersionTLS12, VersionTLS12),
+	suite(0x009d, "TLS_RSA_WITH_AES_256_GCM_SHA384", "RSA", "AES", "GCM", 256, VersionTLS12, VersionTLS12),
+	suite(0xc023, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256", "ECDHE", "AES", "CBC", 128, VersionTLS12, VersionTLS12),
+	suite(0xc024, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384", "ECDHE", "AES", "CBC", 256, VersionTLS12, VersionTLS12),
+	suite(0xc027, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256", "ECDHE", "AES", "CBC", 128, VersionTLS12, VersionTLS12),
+	suite(0xc028, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384", "ECDHE", "AES", "CBC", 256, VersionTLS12, VersionTLS12),
+	suite(0x0067, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256", "DHE", "AES", "CBC", 128, VersionTLS12, VersionTLS12),
+	suite(0x006b, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256", "DHE", "AES", "CBC", 256, VersionTLS12, VersionTLS12),
+	suite(0x003c, "TLS_RSA_WITH_AES_128_CBC_SHA256", "RSA", "AES", "CBC", 128, VersionTLS12, VersionTLS12),
+	suite(0x003d, "TLS_RSA_WITH_AES_256_CBC_SHA256", "RSA", "AES", "CBC", 256, VersionTLS12, VersionTLS12),
+	suite(0x003b, "TLS_RSA_WITH_NULL_SHA256", "RSA", "NULL", "", 0, VersionTLS12, VersionTLS12),
+
+	suite(0xc009, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA", "ECDHE", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0xc00a, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA", "ECDHE", "AES", "CBC", 256, VersionTLS10, VersionTLS12),
+	suite(0xc013, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", "ECDHE", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0xc014, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA", "ECDHE", "AES", "CBC", 256, VersionTLS10, VersionTLS12),
+	suite(0x0033, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA", "DHE", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0x0039, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA", "DHE", "AES", "CBC", 256, VersionTLS10, VersionTLS12),
+	suite(0x002f, "TLS_RSA_WITH_AES_128_CBC_SHA", "RSA", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0x0035, "TLS_RSA_WITH_AES_256_CBC_SHA", "RSA", "AES", "CBC", 256, VersionTLS10, VersionTLS12),
+	suite(0x0041, "TLS_RSA_WITH_CAMELLIA_128_CBC_SHA", "RSA", "CAMELLIA", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0x0084, "TLS_RSA_WITH_CAMELLIA_256_CBC_SHA", "RSA", "CAMELLIA", "CBC", 256, VersionTLS10, VersionTLS12),
+	suite(0xc008, "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA", "ECDHE", "3DES", "CBC", 112, VersionTLS10, VersionTLS12),
+	suite(0xc012, "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA", "ECDHE", "3DES", "CBC", 112, VersionTLS10, VersionTLS12),
+	suite(0x0016, "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA", "DHE", "3DES", "CBC", 112, VersionTLS10, VersionTLS12),
+	suite(0x000a, "TLS_RSA_WITH_3DES_EDE_CBC_SHA", "RSA", "3DES", "CBC", 112, VersionTLS10, VersionTLS12),
+	suite(0xc007, "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA", "ECDHE", "RC4", "", 128, VersionTLS10, VersionTLS12),
+	suite(0xc011, "TLS_ECDHE_RSA_WITH_RC4_128_SHA", "ECDHE", "RC4", "", 128, VersionTLS10, VersionTLS12),
+	suite(0x0005, "TLS_RSA_WITH_RC4_128_SHA", "RSA", "RC4", "", 128, VersionTLS10, VersionTLS12),
+	suite(0x0004, "TLS_RSA_WITH_RC4_128_MD5", "RSA", "RC4", "", 128, VersionTLS10, VersionTLS12),
+	suite(0x0015, "TLS_DHE_RSA_WITH_DES_CBC_SHA", "DHE", "DES", "CBC", 56, VersionTLS10, VersionTLS11),
+	suite(0x0009, "TLS_RSA_WITH_DES_CBC_SHA", "RSA", "DES", "CBC", 56, VersionTLS10, VersionTLS11),
+	suite(0x0034, "TLS_DH_anon_WITH_AES_128_CBC_SHA", "DH_anon", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0xc018, "TLS_ECDH_anon_WITH_AES_128_CBC_SHA", "ECDH_anon", "AES", "CBC", 128, VersionTLS10, VersionTLS12),
+	suite(0xc010, "TLS_ECDHE_RSA_WITH_NULL_SHA", "ECDHE", "NULL", "", 0, VersionTLS10, VersionTLS12),
+	suite(0x0002, "TLS_RSA_WITH_NULL_SHA", "RSA", "NULL", "", 0, VersionTLS10, VersionTLS12),
+	suite(0x0001, "TLS_RSA_WITH_NULL_MD5", "RSA", "NULL", "", 0, VersionTLS10, VersionTLS12),
+
+	exportSuite(0x0064, "TLS_RSA_EXPORT1024_WITH_RC4_56_SHA", "RSA", "RC4", "", 56),
+	exportSuite(0x0062, "TLS_RSA_EXPORT1024_WITH_DES_CBC_SHA", "RSA", "DES", "CBC", 56),
+	exportSuite(0x0014, "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA", "DHE", "DES", "CBC", 40),
+	exportSuite(0x0008, "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA", "RSA", "DES", "CBC", 40),
+	exportSuite(0x0006, "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5", "RSA", "RC2", "CBC", 40),
+	exportSuite(0x0003, "TLS_RSA_EXPORT_WITH_RC4_40_MD5", "RSA", "RC4", "", 40),
+}
+
+// SuiteByID looks up a suite in the catalog.
+func SuiteByID(id uint16) (Suite, bool) {
+	for _, s := range Suites {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Suite{}, false
+}
@@ -0,0 +1,197 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	StatusTrusted             = "Trusted"
+	StatusUntrustedRoot       = "Untrusted Root"
+	StatusHostnameMismatch    = "Hostname Mismatch"
+	StatusExpired             = "Expired Certificate"
+	StatusExpiredIntermediate = "Expired Intermediate"
+	StatusMissingIntermediate = "Missing Intermediate"
+	StatusInvalidChain        = "Invalid Chain"
+)
+
+// Result is the outcome of verifying a presented chain. Chain is the verified
+// path up to a trusted root when Status is StatusTrusted.
+type Result struct {
+	Status  string
+	Message string
+	Chain   []*x509.Certificate
+}
+
+// Trusted reports whether the chain verified and matched the hostname.
+func (r Result) Trusted() bool {
+	return r.Status == StatusTrusted
+}
+
+// LoadCAFile reads a PEM bundle of trusted root certificates.
+func LoadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("failed to read CA file: " + err.Error())
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificates found in CA file: " + path)
+	}
+	return pool, nil
+}
+
+// Verify builds a path from the leaf, using the other presented certificates
+// as intermediates, to a root in roots, or the system pool when roots is nil.
+// The hostname is checked against the leaf's SANs once the path verifies.
+func Verify(certs []*x509.Certificate, host string, roots *x509.CertPool, now time.Time) Result {
+	if len(certs) == 0 {
+		return Result{Status: StatusInvalidChain, Message: "Server did not present a certificate"}
+	}
+
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		return classify(certs, roots, now, err)
+	}
+
+	if host != "" {
+		if err := leaf.VerifyHostname(host); err != nil {
+			return Result{Status: StatusHostnameMismatch, Message: err.Error(), Chain: chains[0]}
+		}
+	}
+	return Result{
+		Status:  StatusTrusted,
+		Message: "Chains to " + name(chains[0][len(chains[0])-1]),
+		Chain:   chains[0],
+	}
+}
+
+// classify turns a verification error into one of the distinct statuses.
+func classify(certs []*x509.Certificate, roots *x509.CertPool, now time.Time, err error) Result {
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		if invalid.Reason == x509.Expired {
+			status := StatusExpiredIntermediate
+			if invalid.Cert == certs[0] {
+				status = StatusExpired
+			}
+			return Result{Status: status, Message: invalid.Error()}
+		}
+		return Result{Status: StatusInvalidChain, Message: invalid.Error()}
+	}
+
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		return Result{Status: StatusInvalidChain, Message: err.Error()}
+	}
+
+	// crypto/x509 skips expired candidates and then reports an unknown
+	// authority, so look for the expired certificate on the presented path.
+	path := presentedPath(certs)
+	for _, cert := range path[1:] {
+		if now.After(cert.NotAfter) || now.Before(cert.NotBefore) {
+			return Result{
+				Status:  StatusExpiredIntermediate,
+				Message: fmt.Sprintf("%s is valid from %s to %s", name(cert), cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02")),
+			}
+		}
+	}
+
+	// Signatures crypto/x509 no longer accepts also surface as an unknown
+	// authority, as does any other link that fails below a trusted top.
+	for _, cert := range path {
+		if insecureSignature(cert) {
+			return Result{
+				Status:  StatusInvalidChain,
+				Message: fmt.Sprintf("%s is signed with %s, which is no longer accepted", name(cert), cert.SignatureAlgorithm),
+			}
+		}
+	}
+	top := path[len(path)-1]
+	if _, topErr := top.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); topErr == nil {
+		return Result{Status: StatusInvalidChain, Message: err.Error()}
+	}
+
+	if isSelfSigned(top) {
+		return Result{Status: StatusUntrustedRoot, Message: name(top) + " is not a trusted root"}
+	}
+	return Result{
+		Status:  StatusMissingIntermediate,
+		Message: fmt.Sprintf("%s was issued by %s, which the server did not send and is not a trusted root", name(top), top.Issuer.String()),
+	}
+}
+
+// presentedPath follows issuers from the leaf through the presented
+// certificates, in case the server sent them out of order or with extras.
+func presentedPath(certs []*x509.Certificate) []*x509.Certificate {
+	path := []*x509.Certificate{certs[0]}
+	used := map[int]bool{0: true}
+	for current := certs[0]; !isSelfSigned(current); {
+		next := -1
+		for i, candidate := range certs {
+			if !used[i] && issuedBy(current, candidate) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		used[next] = true
+		current = certs[next]
+		path = append(path, current)
+	}
+	return path
+}
+
+// issuedBy matches names and key identifiers without checking the signature,
+// so links with signatures crypto/x509 rejects are still followed.
+func issuedBy(cert, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+func insecureSignature(cert *x509.Certificate) bool {
+	if isSelfSigned(cert) {
+		return false
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	}
+	return false
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return issuedBy(cert, cert)
+}
+
+func name(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
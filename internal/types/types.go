@@ -1,8 +1,56 @@
 package types
 
+import "crypto/x509"
+
 type KeyLengthEvaluator interface {
 	GetLength() int
 	GetAlgorithm() string
 	IsSecure(threshold int) bool
 	AdjustForYear(year int) int
 }
+
+// CurveEvaluator is implemented by keys defined over a named elliptic curve.
+type CurveEvaluator interface {
+	GetCurve() string
+}
+
+// Finding is a problem reported by a check other than the key length
+// threshold. Any finding that is not a warning makes the key insecure.
+type Finding struct {
+	Check    string
+	Severity string
+	Message  string
+}
+
+const (
+	// SeverityInvalid marks a key that is malformed rather than weak, such as
+	// an EC point that is not on its curve.
+	SeverityInvalid  = "Invalid"
+	SeverityCritical = "Critical"
+	SeverityFailed   = "Failed"
+	SeverityWarning  = "Warning"
+)
+
+// SanityChecker is implemented by keys that can inspect their own parameters
+// for weaknesses that the key length alone does not reveal.
+type SanityChecker interface {
+	Check() []Finding
+}
+
+// SPKIEncoder is implemented by keys that can return their DER-encoded
+// SubjectPublicKeyInfo, which identifies the key independently of its format.
+type SPKIEncoder interface {
+	SPKI() ([]byte, error)
+}
+
+// CertificateHolder is implemented by keys that may have been read from a
+// certificate. Certificate returns nil for bare keys.
+type CertificateHolder interface {
+	Certificate() *x509.Certificate
+}
+
+// RawCertificateHolder is implemented by keys that may have been read from a
+// certificate crypto/x509 could not parse. ok is false for any other key.
+type RawCertificateHolder interface {
+	RawSignatureAlgorithm() (algorithm x509.SignatureAlgorithm, ok bool)
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	cryptorsa "crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Horiodino/key-length/internal/eval"
+)
+
+func TestEvaluateChain(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	rootKey := generateRSAKey(t, 3072)
+	root := issueCertificate(t, "Test Root", true, rootKey, nil, rootKey, x509.SHA1WithRSA)
+
+	t.Run("WeakIntermediate", func(t *testing.T) {
+		intermediateKey := generateRSAKey(t, 1024)
+		intermediate := issueCertificate(t, "Weak Intermediate", true, intermediateKey, root, rootKey, x509.SHA256WithRSA)
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate ECDSA key: %v", err)
+		}
+		leaf := issueCertificate(t, "leaf.example", false, leafKey, intermediate, intermediateKey, x509.SHA256WithRSA)
+
+		chain := eval.EvaluateChain([]*x509.Certificate{leaf, intermediate, root}, cfg, false, eval.Options{})
+		if len(chain.Links) != 3 {
+			t.Fatalf("Expected 3 links, got %d", len(chain.Links))
+		}
+		positions := []string{"Leaf", "Intermediate 1", "Root"}
+		for i, link := range chain.Links {
+			if link.Position != positions[i] {
+				t.Errorf("Link %d: expected position %q, got %q", i, positions[i], link.Position)
+			}
+		}
+		if chain.Links[0].Result.Status != "Secure (NIST)" {
+			t.Errorf("Expected leaf to be secure, got %q", chain.Links[0].Result.Status)
+		}
+		if chain.Weakest != 1 || chain.Status != "Insecure (NIST)" {
+			t.Errorf("Expected weakest link to be the intermediate with status Insecure, got %d %q", chain.Weakest, chain.Status)
+		}
+		if hasFinding(chain.Links[2].Result, "signature") {
+			t.Errorf("Expected the self-signed root's SHA-1 signature to be ignored, got %+v", chain.Links[2].Result.Findings)
+		}
+	})
+
+	t.Run("SHA1Intermediate", func(t *testing.T) {
+		intermediateKey := generateRSAKey(t, 2048)
+		intermediate := issueCertificate(t, "SHA-1 Intermediate", true, intermediateKey, root, rootKey, x509.SHA1WithRSA)
+		leafKey := generateRSAKey(t, 2048)
+		leaf := issueCertificate(t, "leaf.example", false, leafKey, intermediate, intermediateKey, x509.SHA256WithRSA)
+
+		chain := eval.EvaluateChain([]*x509.Certificate{leaf, intermediate}, cfg, false, eval.Options{})
+		if chain.Weakest != 1 || chain.Status != "Insecure (NIST)" {
+			t.Errorf("Expected SHA-1 intermediate to be the weakest link, got %d %q", chain.Weakest, chain.Status)
+		}
+		if !hasFinding(chain.Links[1].Result, "signature") {
+			t.Errorf("Expected signature finding on intermediate, got %+v", chain.Links[1].Result.Findings)
+		}
+	})
+
+	t.Run("SecureChain", func(t *testing.T) {
+		leafKey := generateRSAKey(t, 2048)
+		leaf := issueCertificate(t, "leaf.example", false, leafKey, root, rootKey, x509.SHA256WithRSA)
+
+		chain := eval.EvaluateChain([]*x509.Certificate{leaf, root}, cfg, false, eval.Options{})
+		if chain.Status != "Secure (NIST)" || chain.Weakest != 0 {
+			t.Errorf("Expected secure chain, got %d %q", chain.Weakest, chain.Status)
+		}
+	})
+}
+
+func generateRSAKey(t *testing.T, bits int) *cryptorsa.PrivateKey {
+	t.Helper()
+	key, err := cryptorsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+// issueCertificate signs a certificate for key with issuerKey. A nil parent
+// makes it self-signed.
+func issueCertificate(t *testing.T, name string, isCA bool, key crypto.Signer, parent *x509.Certificate, issuerKey crypto.Signer, algorithm x509.SignatureAlgorithm) *x509.Certificate {
+	t.Helper()
+	return issueCertificateUntil(t, name, isCA, key, parent, issuerKey, algorithm, time.Now().Add(365*24*time.Hour))
+}
+
+func issueCertificateUntil(t *testing.T, name string, isCA bool, key crypto.Signer, parent *x509.Certificate, issuerKey crypto.Signer, algorithm x509.SignatureAlgorithm, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("Failed to generate serial: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              notAfter,
+		SignatureAlgorithm:    algorithm,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		DNSNames:              []string{name},
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		template.DNSNames = nil
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestCompareBackends(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	rootKey := generateRSAKey(t, 2048)
+	root := issueCertificate(t, "Test Root", true, rootKey, nil, rootKey, x509.SHA256WithRSA)
+	leafKey := generateRSAKey(t, 2048)
+	leaf := issueCertificate(t, "www.example", false, leafKey, root, rootKey, x509.SHA256WithRSA)
+	otherLeaf := issueCertificate(t, "old.example", false, leafKey, root, rootKey, x509.SHA1WithRSA)
+
+	backend := func(address string, cert *x509.Certificate, trust string) eval.Backend {
+		chain := eval.EvaluateChain([]*x509.Certificate{cert, root}, cfg, false, eval.Options{})
+		return eval.Backend{Address: address, Chain: chain, Trust: trust, Leaf: cert}
+	}
+
+	same := []eval.Backend{
+		backend("192.0.2.1:443", leaf, "Trusted"),
+		backend("[2001:db8::1]:443", leaf, "Trusted"),
+	}
+	if differences := eval.CompareBackends(same); differences != nil {
+		t.Errorf("Expected identical backends to agree, got %v", differences)
+	}
+
+	differing := []eval.Backend{
+		backend("192.0.2.1:443", leaf, "Trusted"),
+		backend("[2001:db8::1]:443", otherLeaf, "Invalid Chain"),
+	}
+	differences := eval.CompareBackends(differing)
+	if len(differences) != 3 {
+		t.Fatalf("Expected leaf, trust and verdict differences, got %v", differences)
+	}
+	for i, prefix := range []string{"Leaf certificates differ", "Trust differs", "Chain verdict differs"} {
+		if !strings.HasPrefix(differences[i], prefix) {
+			t.Errorf("Expected difference %d to start with %q, got %q", i, prefix, differences[i])
+		}
+	}
+	if !strings.Contains(differences[0], "[2001:db8::1]:443 serves old.example") {
+		t.Errorf("Expected IPv6 backend to be named with brackets, got %q", differences[0])
+	}
+}
@@ -1 +1,300 @@
 package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	cryptorsa "crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Horiodino/key-length/internal/blocklist"
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/ecc"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/parse"
+	"github.com/Horiodino/key-length/internal/rsa"
+	"github.com/Horiodino/key-length/internal/types"
+)
+
+func TestEvaluateKeyAllowedCurves(t *testing.T) {
+	testCases := []struct {
+		name        string
+		standard    string
+		curve       elliptic.Curve
+		wantStatus  string
+		wantFinding bool
+	}{
+		{"CNSA2AllowsP384", "CNSA2", elliptic.P384(), "Secure (CNSA2)", false},
+		{"CNSA2RejectsP521", "CNSA2", elliptic.P521(), "Insecure (CNSA2)", true},
+		{"NISTAllowsP256", "NIST", elliptic.P256(), "Secure (NIST)", false},
+		{"NISTRejectsP224", "NIST", elliptic.P224(), "Insecure (NIST)", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := loadEmbeddedConfig(t, tc.standard)
+			key, err := ecc.NewECCKey(generateECCCertificate(t, tc.curve))
+			if err != nil {
+				t.Fatalf("Failed to create ECCKey: %v", err)
+			}
+
+			result := eval.EvaluateKey(key, cfg, nil)
+			if result.Status != tc.wantStatus {
+				t.Errorf("Expected status %q, got %q", tc.wantStatus, result.Status)
+			}
+			if result.Curve != tc.curve.Params().Name {
+				t.Errorf("Expected curve %q, got %q", tc.curve.Params().Name, result.Curve)
+			}
+			if got := hasFinding(result, "curve"); got != tc.wantFinding {
+				t.Errorf("Expected curve finding %v, got findings %+v", tc.wantFinding, result.Findings)
+			}
+		})
+	}
+}
+
+func hasFinding(result *eval.EvaluationResult, check string) bool {
+	for _, finding := range result.Findings {
+		if finding.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+func loadEmbeddedConfig(t *testing.T, standard string) *config.Config {
+	t.Helper()
+	cfg, err := config.NewLayeredConfig([]config.Layer{config.EmbeddedLayer()}, standard)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return cfg
+}
+
+func generateECCCertificate(t *testing.T, curve elliptic.Curve) []byte {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test ECC Cert"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
+
+func TestEvaluateKeyBlocklist(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	certPEM := generateECCCertificate(t, elliptic.P256())
+	parsed, err := parse.ParseData(certPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	fingerprint, err := parsed.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error: %v", err)
+	}
+	if fingerprint != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected fingerprint %x, got %s", sum, fingerprint)
+	}
+
+	path := filepath.Join(t.TempDir(), "leaked.txt")
+	if err := os.WriteFile(path, []byte(fingerprint+" leaked test key\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+	keys, err := blocklist.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load blocklist: %v", err)
+	}
+
+	result := eval.EvaluateKeyWithOptions(parsed.Key.(types.KeyLengthEvaluator), cfg, nil, eval.Options{KeyBlocklist: keys})
+	if !hasFinding(result, "key-blocklist") {
+		t.Errorf("Expected key-blocklist finding, got %+v", result.Findings)
+	}
+	if result.Status != "Insecure (NIST)" {
+		t.Errorf("Expected blocklisted key to be insecure, got %q", result.Status)
+	}
+}
+
+func TestEvaluateKeySignature(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.json")
+	custom := `{"standards": {"PSS": {"RSA": 2048, "ECC": 256, "Symmetric": 128, "cut_off_year": 2030,
+		"allowed_hashes": ["SHA256", "SHA-384"], "require_pss": true}}}`
+	if err := os.WriteFile(path, []byte(custom), 0o600); err != nil {
+		t.Fatalf("Failed to write standards: %v", err)
+	}
+	pssConfig, err := config.NewConfig(path, "PSS")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		cfg          *config.Config
+		curve        elliptic.Curve
+		algorithm    x509.SignatureAlgorithm
+		wantStatus   string
+		wantSeverity string
+	}{
+		{"SHA1Rejected", loadEmbeddedConfig(t, "NIST"), nil, x509.SHA1WithRSA, "Insecure (NIST)", eval.SeverityCritical},
+		{"SHA256Allowed", loadEmbeddedConfig(t, "NIST"), nil, x509.SHA256WithRSA, "Secure (NIST)", ""},
+		{"PKCS1RejectedWhenPSSRequired", pssConfig, nil, x509.SHA256WithRSA, "Insecure (PSS)", eval.SeverityFailed},
+		{"PSSAccepted", pssConfig, nil, x509.SHA256WithRSAPSS, "Secure (PSS)", ""},
+		{"HashNotAllowed", loadEmbeddedConfig(t, "CNSA2"), elliptic.P384(), x509.ECDSAWithSHA256, "Insecure (CNSA2)", eval.SeverityFailed},
+		{"HashAllowed", loadEmbeddedConfig(t, "CNSA2"), elliptic.P384(), x509.ECDSAWithSHA384, "Secure (CNSA2)", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var key types.KeyLengthEvaluator
+			if tc.curve != nil {
+				key, err = ecc.NewECCKey(generateSignedCertificate(t, tc.curve, tc.algorithm))
+			} else {
+				key, err = rsa.NewRSAKey(generateSignedCertificate(t, nil, tc.algorithm))
+			}
+			if err != nil {
+				t.Fatalf("Failed to create key: %v", err)
+			}
+
+			result := eval.EvaluateKey(key, tc.cfg, nil)
+			if result.SignatureAlgorithm != tc.algorithm.String() {
+				t.Errorf("Expected signature algorithm %s, got %q", tc.algorithm, result.SignatureAlgorithm)
+			}
+			if result.Status != tc.wantStatus {
+				t.Errorf("Expected status %q, got %q (findings %+v)", tc.wantStatus, result.Status, result.Findings)
+			}
+			severity := ""
+			for _, finding := range result.Findings {
+				if finding.Check == "signature" {
+					severity = finding.Severity
+				}
+			}
+			if severity != tc.wantSeverity {
+				t.Errorf("Expected signature severity %q, got %q", tc.wantSeverity, severity)
+			}
+		})
+	}
+}
+
+// TestEvaluateKeyRawCertificateSignature checks the signature of a
+// certificate on a curve crypto/x509 rejects, which is parsed without it.
+func TestEvaluateKeyRawCertificateSignature(t *testing.T) {
+	point := make([]byte, 97)
+	point[0] = 4
+	if _, err := rand.Read(point[1:]); err != nil {
+		t.Fatalf("Failed to generate point: %v", err)
+	}
+	curve, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11})
+	if err != nil {
+		t.Fatalf("Failed to marshal curve OID: %v", err)
+	}
+	type spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	sha1WithRSA := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}}
+	name := pkix.Name{CommonName: "Test Brainpool Cert"}.ToRDNSequence()
+	cert := struct {
+		TBS struct {
+			Version      int `asn1:"optional,explicit,default:0,tag:0"`
+			SerialNumber *big.Int
+			Signature    pkix.AlgorithmIdentifier
+			Issuer       pkix.RDNSequence
+			Validity     struct{ NotBefore, NotAfter time.Time }
+			Subject      pkix.RDNSequence
+			PublicKey    spki
+		}
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{}
+	cert.TBS.Version = 2
+	cert.TBS.SerialNumber = big.NewInt(1)
+	cert.TBS.Signature = sha1WithRSA
+	cert.TBS.Issuer = pkix.Name{CommonName: "Test Issuer"}.ToRDNSequence()
+	cert.TBS.Validity.NotBefore = time.Now().UTC().Truncate(time.Second)
+	cert.TBS.Validity.NotAfter = cert.TBS.Validity.NotBefore.Add(365 * 24 * time.Hour)
+	cert.TBS.Subject = name
+	cert.TBS.PublicKey = spki{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, Parameters: asn1.RawValue{FullBytes: curve}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: len(point) * 8},
+	}
+	cert.SignatureAlgorithm = sha1WithRSA
+	cert.Signature = asn1.BitString{Bytes: []byte{0}, BitLength: 8}
+	der, err := asn1.Marshal(cert)
+	if err != nil {
+		t.Fatalf("Failed to marshal certificate: %v", err)
+	}
+
+	key, err := ecc.NewECCKey(der)
+	if err != nil {
+		t.Fatalf("Failed to create ECCKey: %v", err)
+	}
+	result := eval.EvaluateKey(key, loadEmbeddedConfig(t, "BSI"), nil)
+	if result.SignatureAlgorithm != x509.SHA1WithRSA.String() {
+		t.Errorf("Expected signature algorithm %s, got %q", x509.SHA1WithRSA, result.SignatureAlgorithm)
+	}
+	severity := ""
+	for _, finding := range result.Findings {
+		if finding.Check == "signature" {
+			severity = finding.Severity
+		}
+	}
+	if severity != eval.SeverityCritical {
+		t.Errorf("Expected a critical signature finding, got %+v", result.Findings)
+	}
+}
+
+// generateSignedCertificate creates a self-signed PEM certificate with the
+// given signature algorithm, using an RSA key when curve is nil.
+func generateSignedCertificate(t *testing.T, curve elliptic.Curve, algorithm x509.SignatureAlgorithm) []byte {
+	t.Helper()
+	var publicKey, privateKey any
+	if curve != nil {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate ECDSA key: %v", err)
+		}
+		publicKey, privateKey = &key.PublicKey, key
+	} else {
+		key, err := cryptorsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		publicKey, privateKey = &key.PublicKey, key
+	}
+	template := x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "Test Signature Cert"},
+		NotBefore:          time.Now(),
+		NotAfter:           time.Now().Add(365 * 24 * time.Hour),
+		SignatureAlgorithm: algorithm,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+}
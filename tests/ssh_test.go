@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/sshprobe"
+	"golang.org/x/crypto/ssh"
+)
+
+func sshPublicKey(t *testing.T, key interface{}) ssh.PublicKey {
+	t.Helper()
+	pub, err := ssh.NewPublicKey(key)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+	return pub
+}
+
+func TestEvaluateSSHHostKey(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	var dsaKey dsa.PrivateKey
+	dsa.GenerateParameters(&dsaKey.Parameters, rand.Reader, dsa.L1024N160)
+	dsa.GenerateKey(&dsaKey, rand.Reader)
+
+	testCases := []struct {
+		name          string
+		key           interface{}
+		algorithms    []string
+		wantStatus    string
+		wantAlgorithm string
+		wantChecks    []string
+	}{
+		{"RSA2048", &generateRSAKey(t, 2048).PublicKey, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256}, "Secure (NIST)", "RSA", nil},
+		{"RSAAlsoSHA1", &generateRSAKey(t, 2048).PublicKey, []string{ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}, "Secure (NIST)", "RSA", []string{"ssh-signature"}},
+		{"RSAOnlySHA1", &generateRSAKey(t, 2048).PublicKey, []string{ssh.KeyAlgoRSA}, "Insecure (NIST)", "RSA", []string{"ssh-signature"}},
+		{"RSA1024", &generateRSAKey(t, 1024).PublicKey, []string{ssh.KeyAlgoRSASHA256}, "Insecure (NIST)", "RSA", nil},
+		{"ECDSAP256", &p256.PublicKey, []string{ssh.KeyAlgoECDSA256}, "Secure (NIST)", "ECC", nil},
+		{"ECDSAP384", &p384.PublicKey, []string{ssh.KeyAlgoECDSA384}, "Secure (NIST)", "ECC", nil},
+		{"Ed25519", edKey, []string{ssh.KeyAlgoED25519}, "Secure (NIST)", "Ed25519", nil},
+		{"DSA", &dsaKey.PublicKey, []string{ssh.KeyAlgoDSA}, "Insecure (NIST)", "DSA", []string{"ssh-host-key", "ssh-signature"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hostKey := sshprobe.HostKey{Key: sshPublicKey(t, tc.key), Algorithms: tc.algorithms}
+			result := eval.EvaluateSSHHostKey(hostKey, cfg, eval.Options{})
+			if result.Status != tc.wantStatus {
+				t.Errorf("Expected status %q, got %q (%+v)", tc.wantStatus, result.Status, result.Findings)
+			}
+			if result.Algorithm != tc.wantAlgorithm {
+				t.Errorf("Expected algorithm %s, got %s", tc.wantAlgorithm, result.Algorithm)
+			}
+			if len(result.Findings) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %+v", tc.wantChecks, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateSSHKeyExchange(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+
+	testCases := []struct {
+		name       string
+		bits       int
+		wantSecure bool
+		wantChecks []string
+	}{
+		{"curve25519-sha256", 0, true, nil},
+		{"ecdh-sha2-nistp384", 0, true, nil},
+		{"sntrup761x25519-sha512@openssh.com", 0, true, nil},
+		{"diffie-hellman-group16-sha512", 0, true, nil},
+		{"diffie-hellman-group14-sha1", 0, false, []string{"ssh-kex-hash"}},
+		{"diffie-hellman-group1-sha1", 0, false, []string{"ssh-kex-group", "ssh-kex-hash"}},
+		{"diffie-hellman-group-exchange-sha256", 0, true, []string{"ssh-kex-group"}},
+		{"diffie-hellman-group-exchange-sha256", 3072, true, nil},
+		{"diffie-hellman-group-exchange-sha256", 1024, false, []string{"ssh-kex-group"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kex, ok := sshprobe.KeyExchangeByName(tc.name)
+			if !ok {
+				t.Fatalf("%s is not in the catalog", tc.name)
+			}
+			if tc.bits > 0 {
+				kex.Bits = tc.bits
+			}
+			result := eval.EvaluateSSHKeyExchange(kex, cfg)
+			wantStatus := "Insecure (NIST)"
+			if tc.wantSecure {
+				wantStatus = "Secure (NIST)"
+			}
+			if result.Status != wantStatus {
+				t.Errorf("Expected status %q, got %q (%+v)", wantStatus, result.Status, result.Findings)
+			}
+			if len(result.Findings) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %+v", tc.wantChecks, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateSSHCipherAndMAC(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+
+	ciphers := []struct {
+		name       string
+		wantSecure bool
+		wantChecks []string
+	}{
+		{"chacha20-poly1305@openssh.com", true, nil},
+		{"aes128-gcm@openssh.com", true, nil},
+		{"aes256-ctr", true, nil},
+		{"aes128-cbc", true, []string{"ssh-cipher-cbc"}},
+		{"3des-cbc", false, []string{"ssh-cipher-64bit-block", "ssh-cipher-key-length", "ssh-cipher-cbc"}},
+		{"arcfour256", false, []string{"ssh-cipher-rc4"}},
+		{"none", false, []string{"ssh-cipher-none"}},
+	}
+	for _, tc := range ciphers {
+		t.Run(tc.name, func(t *testing.T) {
+			cipher, ok := sshprobe.CipherByName(tc.name)
+			if !ok {
+				t.Fatalf("%s is not in the catalog", tc.name)
+			}
+			result := eval.EvaluateSSHCipher(cipher, cfg)
+			if secure := result.Status == "Secure (NIST)"; secure != tc.wantSecure {
+				t.Errorf("Expected secure=%v, got %q (%+v)", tc.wantSecure, result.Status, result.Findings)
+			}
+			if len(result.Findings) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %+v", tc.wantChecks, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+
+	macs := []struct {
+		name       string
+		wantSecure bool
+		wantChecks []string
+	}{
+		{"hmac-sha2-256-etm@openssh.com", true, nil},
+		{"hmac-sha2-512", true, []string{"ssh-mac-etm"}},
+		{"hmac-sha1-96", true, []string{"ssh-mac-hash", "ssh-mac-tag", "ssh-mac-etm"}},
+		{"umac-64-etm@openssh.com", true, []string{"ssh-mac-tag"}},
+		{"hmac-md5", false, []string{"ssh-mac-hash", "ssh-mac-etm"}},
+		{"none", false, []string{"ssh-mac-none"}},
+	}
+	for _, tc := range macs {
+		t.Run(tc.name, func(t *testing.T) {
+			mac, ok := sshprobe.MACByName(tc.name)
+			if !ok {
+				t.Fatalf("%s is not in the catalog", tc.name)
+			}
+			result := eval.EvaluateSSHMAC(mac, cfg)
+			if secure := result.Status == "Secure (NIST)"; secure != tc.wantSecure {
+				t.Errorf("Expected secure=%v, got %q (%+v)", tc.wantSecure, result.Status, result.Findings)
+			}
+			if len(result.Findings) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %+v", tc.wantChecks, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateSSHProtocol(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+
+	testCases := []struct {
+		name       string
+		algorithms sshprobe.Algorithms
+		wantChecks []string
+	}{
+		{"StrictKex", sshprobe.Algorithms{Banner: "SSH-2.0-OpenSSH_9.6", Ciphers: []string{"chacha20-poly1305@openssh.com"}, StrictKex: true}, nil},
+		{"ChaCha20WithoutStrictKex", sshprobe.Algorithms{Banner: "SSH-2.0-OpenSSH_8.9", Ciphers: []string{"chacha20-poly1305@openssh.com", "aes128-ctr"}}, []string{"ssh-terrapin"}},
+		{"CBCWithETM", sshprobe.Algorithms{Banner: "SSH-2.0-dropbear", Ciphers: []string{"aes128-cbc"}, MACs: []string{"hmac-sha2-256-etm@openssh.com"}}, []string{"ssh-terrapin"}},
+		{"CBCWithoutETM", sshprobe.Algorithms{Banner: "SSH-2.0-dropbear", Ciphers: []string{"aes128-cbc"}, MACs: []string{"hmac-sha2-256"}}, nil},
+		{"SSH1", sshprobe.Algorithms{Banner: "SSH-1.99-OpenSSH_3.9", Ciphers: []string{"aes128-ctr"}}, []string{"ssh-protocol"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval.EvaluateSSHProtocol(&tc.algorithms, cfg)
+			wantStatus := "Secure (NIST)"
+			if len(tc.wantChecks) > 0 {
+				wantStatus = "Insecure (NIST)"
+			}
+			if result.Status != wantStatus {
+				t.Errorf("Expected status %q, got %q (%+v)", wantStatus, result.Status, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+
+	result := eval.EvaluateSSHProtocol(&sshprobe.Algorithms{Banner: "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3"}, cfg)
+	if result.Algorithm != "OpenSSH_9.6p1 Ubuntu-3" {
+		t.Errorf("Expected the software version, got %q", result.Algorithm)
+	}
+}
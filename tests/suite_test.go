@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Horiodino/key-length/internal/config"
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/tlsprobe"
+)
+
+func TestEvaluateSuite(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+
+	testCases := []struct {
+		name       string
+		id         uint16
+		wantSecure bool
+		wantChecks []string
+	}{
+		{"TLS13AES128", 0x1301, true, nil},
+		{"ECDHEChaCha20", 0xcca8, true, nil},
+		{"ECDHEAES128CBC", 0xc013, true, []string{"cipher-aead"}},
+		{"3DES", 0x000a, false, []string{"cipher-3des", "cipher-key-length", "cipher-aead"}},
+		{"RC4", 0x0005, false, []string{"cipher-rc4"}},
+		{"Export", 0x0003, false, []string{"cipher-export", "cipher-key-length"}},
+		{"NULL", 0x0002, false, []string{"cipher-null"}},
+		{"Anonymous", 0x0034, false, []string{"cipher-anonymous", "cipher-aead"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			suite, ok := tlsprobe.SuiteByID(tc.id)
+			if !ok {
+				t.Fatalf("Suite 0x%04x is not in the catalog", tc.id)
+			}
+			result := eval.EvaluateSuite(suite, cfg)
+			wantStatus := "Insecure (NIST)"
+			if tc.wantSecure {
+				wantStatus = "Secure (NIST)"
+			}
+			if result.Status != wantStatus {
+				t.Errorf("Expected status %q, got %q (%+v)", wantStatus, result.Status, result.Findings)
+			}
+			if len(result.Findings) != len(tc.wantChecks) {
+				t.Errorf("Expected findings %v, got %+v", tc.wantChecks, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected %s finding, got %+v", check, result.Findings)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateProtocol(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	gcm, _ := tlsprobe.SuiteByID(0xc02f)
+	cbc, _ := tlsprobe.SuiteByID(0xc013)
+
+	testCases := []struct {
+		name      string
+		protocol  tlsprobe.Protocol
+		wantCheck string
+	}{
+		{"TLS10", tlsprobe.Protocol{Version: tlsprobe.VersionTLS10, Name: "TLS 1.0", Supported: true, Suites: []tlsprobe.Suite{cbc}}, "protocol-version"},
+		{"TLS12CBCOnly", tlsprobe.Protocol{Version: tlsprobe.VersionTLS12, Name: "TLS 1.2", Supported: true, Suites: []tlsprobe.Suite{cbc}}, "protocol-cbc-only"},
+		{"TLS12WithAEAD", tlsprobe.Protocol{Version: tlsprobe.VersionTLS12, Name: "TLS 1.2", Supported: true, Suites: []tlsprobe.Suite{gcm, cbc}}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval.EvaluateProtocol(tc.protocol, cfg)
+			if tc.wantCheck == "" {
+				if result.Status != "Secure (NIST)" {
+					t.Errorf("Expected secure protocol, got %q (%+v)", result.Status, result.Findings)
+				}
+				return
+			}
+			if result.Status != "Insecure (NIST)" || !hasFinding(result, tc.wantCheck) {
+				t.Errorf("Expected insecure with %s finding, got %q (%+v)", tc.wantCheck, result.Status, result.Findings)
+			}
+		})
+	}
+
+	if result := eval.EvaluateProtocol(tlsprobe.Protocol{Version: tlsprobe.VersionTLS11, Name: "TLS 1.1"}, cfg); result != nil {
+		t.Errorf("Expected nil result for a version that is not offered, got %+v", result)
+	}
+}
+
+func TestEvaluateGroup(t *testing.T) {
+	nist := loadEmbeddedConfig(t, "NIST")
+	bsi := loadEmbeddedConfig(t, "BSI")
+
+	testCases := []struct {
+		name       string
+		cfg        *config.Config
+		group      tlsprobe.Group
+		wantSecure bool
+		wantCheck  string
+	}{
+		{"X25519", nist, tlsprobe.Group{Name: "X25519", Kind: tlsprobe.KindECDHE, Bits: 256}, true, ""},
+		{"Hybrid", nist, tlsprobe.Group{Name: "X25519MLKEM768", Kind: tlsprobe.KindHybrid, Bits: 256}, true, ""},
+		{"FFDHE2048UnderNIST", nist, tlsprobe.Group{Name: "ffdhe2048", Kind: tlsprobe.KindFFDHE, Bits: 2048}, true, ""},
+		{"FFDHE2048UnderBSI", bsi, tlsprobe.Group{Name: "ffdhe2048", Kind: tlsprobe.KindFFDHE, Bits: 2048}, false, "kex-group"},
+		{"CustomDHE1024", nist, tlsprobe.Group{Name: "DHE", Kind: tlsprobe.KindFFDHE, Bits: 1024}, false, "kex-group"},
+		{"ExportDHE512", nist, tlsprobe.Group{Name: "DHE", Kind: tlsprobe.KindFFDHE, Bits: 512}, false, "kex-group"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval.EvaluateGroup(tc.group, tc.cfg)
+			if strings.HasPrefix(result.Status, "Secure") != tc.wantSecure {
+				t.Errorf("Expected secure=%v, got %q (%+v)", tc.wantSecure, result.Status, result.Findings)
+			}
+			if tc.wantCheck != "" && !hasFinding(result, tc.wantCheck) {
+				t.Errorf("Expected %s finding, got %+v", tc.wantCheck, result.Findings)
+			}
+		})
+	}
+
+	result := eval.EvaluateGroup(tlsprobe.Group{Name: "DHE", Kind: tlsprobe.KindFFDHE, Bits: 512}, nist)
+	if len(result.Findings) != 1 || result.Findings[0].Severity != eval.SeverityCritical {
+		t.Errorf("Expected a single critical finding for a 512-bit prime, got %+v", result.Findings)
+	}
+}
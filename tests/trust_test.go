@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/Horiodino/key-length/internal/eval"
+	"github.com/Horiodino/key-length/internal/revocation"
+	"github.com/Horiodino/key-length/internal/trust"
+)
+
+func TestVerifyTrust(t *testing.T) {
+	rootKey := generateRSAKey(t, 2048)
+	root := issueCertificate(t, "Test Root", true, rootKey, nil, rootKey, x509.SHA256WithRSA)
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	intermediateKey := generateRSAKey(t, 2048)
+	intermediate := issueCertificate(t, "Test Intermediate", true, intermediateKey, root, rootKey, x509.SHA256WithRSA)
+	leafKey := generateRSAKey(t, 2048)
+	leaf := issueCertificate(t, "leaf.example", false, leafKey, intermediate, intermediateKey, x509.SHA256WithRSA)
+
+	expiredIntermediate := issueCertificateUntil(t, "Expired Intermediate", true, intermediateKey, root, rootKey, x509.SHA256WithRSA, time.Now().Add(-time.Hour))
+	leafOfExpired := issueCertificate(t, "leaf.example", false, leafKey, expiredIntermediate, intermediateKey, x509.SHA256WithRSA)
+
+	expiredLeaf := issueCertificateUntil(t, "leaf.example", false, leafKey, intermediate, intermediateKey, x509.SHA256WithRSA, time.Now().Add(-time.Hour))
+
+	sha1Intermediate := issueCertificate(t, "SHA-1 Intermediate", true, intermediateKey, root, rootKey, x509.SHA1WithRSA)
+	leafOfSHA1 := issueCertificate(t, "leaf.example", false, leafKey, sha1Intermediate, intermediateKey, x509.SHA256WithRSA)
+
+	testCases := []struct {
+		name   string
+		certs  []*x509.Certificate
+		host   string
+		roots  *x509.CertPool
+		status string
+	}{
+		{"Trusted", []*x509.Certificate{leaf, intermediate}, "leaf.example", roots, trust.StatusTrusted},
+		{"TrustedWithRoot", []*x509.Certificate{leaf, intermediate, root}, "leaf.example", roots, trust.StatusTrusted},
+		{"UntrustedRoot", []*x509.Certificate{leaf, intermediate, root}, "leaf.example", x509.NewCertPool(), trust.StatusUntrustedRoot},
+		{"HostnameMismatch", []*x509.Certificate{leaf, intermediate}, "other.example", roots, trust.StatusHostnameMismatch},
+		{"MissingIntermediate", []*x509.Certificate{leaf}, "leaf.example", roots, trust.StatusMissingIntermediate},
+		{"ExpiredIntermediate", []*x509.Certificate{leafOfExpired, expiredIntermediate}, "leaf.example", roots, trust.StatusExpiredIntermediate},
+		{"ExpiredLeaf", []*x509.Certificate{expiredLeaf, intermediate}, "leaf.example", roots, trust.StatusExpired},
+		{"SHA1Intermediate", []*x509.Certificate{leafOfSHA1, sha1Intermediate}, "leaf.example", roots, trust.StatusInvalidChain},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := trust.Verify(tc.certs, tc.host, tc.roots, time.Now())
+			if result.Status != tc.status {
+				t.Errorf("Expected status %q, got %q (%s)", tc.status, result.Status, result.Message)
+			}
+			if result.Trusted() != (tc.status == trust.StatusTrusted) {
+				t.Errorf("Trusted() = %v for status %q", result.Trusted(), result.Status)
+			}
+		})
+	}
+}
+
+func TestEvaluateRevocation(t *testing.T) {
+	cfg := loadEmbeddedConfig(t, "NIST")
+	strongKey := generateRSAKey(t, 2048)
+	strong := issueCertificate(t, "Strong CA", true, strongKey, nil, strongKey, x509.SHA256WithRSA)
+	weakKey := generateRSAKey(t, 1024)
+	weak := issueCertificate(t, "Weak CA", true, weakKey, nil, weakKey, x509.SHA256WithRSA)
+
+	testCases := []struct {
+		name       string
+		result     revocation.Result
+		wantStatus string
+		wantChecks []string
+	}{
+		{"Good", revocation.Result{Status: revocation.StatusGood, Source: revocation.SourceOCSP, Signer: strong, SignatureAlgorithm: x509.SHA256WithRSA}, "Secure (NIST)", nil},
+		{"Revoked", revocation.Result{Status: revocation.StatusRevoked, Source: revocation.SourceCRL, Signer: strong, SignatureAlgorithm: x509.SHA256WithRSA, RevokedAt: time.Now()}, "Insecure (NIST)", []string{"revocation"}},
+		{"Unknown", revocation.Result{Status: revocation.StatusUnknown, Message: "No stapled OCSP response"}, "Secure (NIST)", []string{"revocation"}},
+		{"WeakSigner", revocation.Result{Status: revocation.StatusGood, Source: revocation.SourceStapled, Signer: weak, SignatureAlgorithm: x509.SHA256WithRSA}, "Insecure (NIST)", []string{"revocation-signer"}},
+		{"SHA1Response", revocation.Result{Status: revocation.StatusGood, Source: revocation.SourceOCSP, Signer: strong, SignatureAlgorithm: x509.SHA1WithRSA}, "Insecure (NIST)", []string{"revocation-signature"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval.EvaluateRevocation(tc.result, cfg, eval.Options{})
+			if result.Status != tc.wantStatus {
+				t.Errorf("Expected %s, got %s (%v)", tc.wantStatus, result.Status, result.Findings)
+			}
+			for _, check := range tc.wantChecks {
+				if !hasFinding(result, check) {
+					t.Errorf("Expected a %s finding, got %v", check, result.Findings)
+				}
+			}
+			if len(tc.wantChecks) == 0 && len(result.Findings) > 0 {
+				t.Errorf("Expected no findings, got %v", result.Findings)
+			}
+		})
+	}
+}